@@ -20,19 +20,20 @@ package cleanup
 import (
 	"fmt"
 
-	"github.com/apache/skywalking-infra-e2e/internal/config"
-
-	"github.com/apache/skywalking-infra-e2e/internal/components/cleanup"
-
 	"github.com/spf13/cobra"
 
-	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/components/environment"
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 )
 
 var Cleanup = &cobra.Command{
 	Use:   "cleanup",
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := state.Restore(&config.GlobalConfig.E2EConfig); err != nil {
+			return fmt.Errorf("[Cleanup] %s", err)
+		}
 		err := DoCleanupAccordingE2E()
 		if err != nil {
 			err = fmt.Errorf("[Cleanup] %s", err)
@@ -43,26 +44,14 @@ var Cleanup = &cobra.Command{
 }
 
 func DoCleanupAccordingE2E() error {
-	e2eConfig := config.GlobalConfig.E2EConfig
-
-	switch e2eConfig.Setup.Env {
-	case constant.Kind:
-		kubeConfigPath := e2eConfig.Setup.GetKubeconfig()
-		// if there is an existing kubernetes cluster, don't delete the kind cluster.
-		if kubeConfigPath == "" {
-			err := cleanup.KindCleanUp(&e2eConfig)
-			if err != nil {
-				return err
-			}
-		}
-	case constant.Compose:
-		err := cleanup.ComposeCleanUp(&e2eConfig)
-		if err != nil {
-			return err
-		}
-	default:
-		return fmt.Errorf("no such env for cleanup: [%s]. should use kind or compose instead", e2eConfig.Setup.Env)
+	env, err := environment.New(&config.GlobalConfig.E2EConfig)
+	if err != nil {
+		return err
+	}
+	if err := env.Cleanup(); err != nil {
+		return err
 	}
 
-	return nil
+	// the environment is gone, the following commands should not use its state
+	return state.Remove()
 }
@@ -24,6 +24,7 @@ import (
 
 	"github.com/apache/skywalking-infra-e2e/internal/components/collector"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 )
 
 var Collect = &cobra.Command{
@@ -33,6 +34,9 @@ var Collect = &cobra.Command{
 		if config.GlobalConfig.Error != nil {
 			return config.GlobalConfig.Error
 		}
+		if err := state.Restore(&config.GlobalConfig.E2EConfig); err != nil {
+			return fmt.Errorf("[Collect] %v", err)
+		}
 		err := collector.DoCollect(&config.GlobalConfig.E2EConfig)
 		if err != nil {
 			return fmt.Errorf("[Collect] %s", err)
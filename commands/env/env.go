@@ -0,0 +1,71 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package env
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+var export bool
+
+func init() {
+	Env.Flags().BoolVarP(&export, "export", "", false, `prefix the variables with "export", so they could be evaluated by shells`)
+}
+
+// Env prints the environment variables exported by the setup, such as the hosts and ports of the services.
+var Env = &cobra.Command{
+	Use:   "env",
+	Short: "Print the environment variables exported by the setup",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if config.GlobalConfig.Error != nil {
+			return config.GlobalConfig.Error
+		}
+		if err := state.Restore(&config.GlobalConfig.E2EConfig); err != nil {
+			return fmt.Errorf("[Env] %v", err)
+		}
+		if state.Current == nil {
+			return fmt.Errorf("[Env] no setup found in %s, please run the setup first", util.WorkDir)
+		}
+
+		vars := state.Current.Variables
+		keys := make([]string, 0, len(vars))
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var b strings.Builder
+		for _, key := range keys {
+			if export {
+				b.WriteString("export ")
+			}
+			b.WriteString(util.FormatDotenv(map[string]string{key: vars[key]}))
+		}
+		_, err := os.Stdout.WriteString(b.String())
+		return err
+	},
+}
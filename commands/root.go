@@ -25,10 +25,14 @@ import (
 
 	"github.com/apache/skywalking-infra-e2e/commands/cleanup"
 	"github.com/apache/skywalking-infra-e2e/commands/collect"
+	"github.com/apache/skywalking-infra-e2e/commands/env"
 	"github.com/apache/skywalking-infra-e2e/commands/run"
+	"github.com/apache/skywalking-infra-e2e/commands/schema"
 	"github.com/apache/skywalking-infra-e2e/commands/setup"
 	"github.com/apache/skywalking-infra-e2e/commands/trigger"
+	"github.com/apache/skywalking-infra-e2e/commands/validate"
 	"github.com/apache/skywalking-infra-e2e/commands/verify"
+	_ "github.com/apache/skywalking-infra-e2e/internal/components/environment/providers" // the built-in environments
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
@@ -47,6 +51,10 @@ var Root = &cobra.Command{
 	SilenceErrors: true,
 	SilenceUsage:  true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// the rendered manifests are printed to stdout, keep them as valid YAML
+		if setup.RenderOnly {
+			logger.Log.SetOutput(os.Stderr)
+		}
 		config.ReadGlobalConfigFile()
 
 		level, err := logrus.ParseLevel(verbosity)
@@ -94,11 +102,19 @@ func Execute() error {
 	Root.AddCommand(verify.Verify)
 	Root.AddCommand(cleanup.Cleanup)
 	Root.AddCommand(collect.Collect)
+	Root.AddCommand(validate.Validate)
+	Root.AddCommand(schema.Schema)
+	Root.AddCommand(env.Env)
 
 	Root.PersistentFlags().StringVarP(&verbosity, "verbosity", "v", logrus.InfoLevel.String(), "log level (debug, info, warn, error, fatal, panic")
 	Root.PersistentFlags().StringVarP(&util.WorkDir, "work-dir", "w", "~/.skywalking-infra-e2e", "the working directory for skywalking-infra-e2e")
 	Root.PersistentFlags().StringVarP(&util.LogDir, "log-dir", "l", "~/.skywalking-infra-e2e/logs", "the container logs directory for environment")
 	Root.PersistentFlags().StringVarP(&util.CfgFile, "config", "c", constant.E2EDefaultFile, "the config file")
+	Root.PersistentFlags().StringArrayVarP(&util.Overrides, "set", "", nil,
+		`override a key of the config file, such as "verify.retry.count=3", could be repeated.
+Also could be set by the environment variables such as E2E_SET_VERIFY__RETRY__COUNT=3, which are overridden by this flag.`)
+	Root.PersistentFlags().IntVarP(&util.MatrixIndex, "matrix-index", "", -1,
+		"the index of the matrix combination to run, required by the separate steps when the config file has a matrix")
 	Root.PersistentFlags().BoolVarP(&util.BatchMode, "batch-mode", "B", false,
 		`whether to run in batch mode, if true, all interactive operations are disabled, including real-time progress bar.
 This option is always enabled in concurrency mode and in our GitHub Actions.`)
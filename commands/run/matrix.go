@@ -0,0 +1,199 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package run
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+var unsafeDirCharsRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// matrixFlags are the flags set for each combination, instead of being inherited from the parent process.
+var matrixFlags = map[string]bool{
+	"matrix-index": true,
+	"work-dir":     true,
+	"log-dir":      true,
+	"batch-mode":   true,
+	"parallel":     true,
+}
+
+// matrixRun is the run of a matrix combination.
+type matrixRun struct {
+	index       int
+	combination config.MatrixCombination
+	workDir     string
+	logDir      string
+	// outputFile is the file the output of the run is written to when running in parallel.
+	outputFile string
+	duration   time.Duration
+	err        error
+}
+
+// runMatrix runs every combination of the matrix in its own e2e process, so that the combinations
+// don't share the environment variables, and then prints a combined summary.
+func runMatrix(cmd *cobra.Command) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find the e2e executable to run the matrix: %v", err)
+	}
+	inheritedArgs := inheritedFlags(cmd)
+
+	combinations := config.GlobalConfig.E2EConfig.MatrixCombinations()
+	logger.Log.Infof("running %d matrix combination(s)", len(combinations))
+
+	runs := make([]*matrixRun, len(combinations))
+	var wg sync.WaitGroup
+	for idx, combination := range combinations {
+		dir := fmt.Sprintf("matrix-%d-%s", idx, unsafeDirCharsRegex.ReplaceAllString(combination.String(), "_"))
+		runs[idx] = &matrixRun{
+			index:       idx,
+			combination: combination,
+			workDir:     filepath.Join(util.WorkDir, dir),
+			logDir:      filepath.Join(util.LogDir, dir),
+		}
+		if !parallel {
+			runs[idx].run(executable, inheritedArgs)
+			continue
+		}
+		wg.Add(1)
+		go func(r *matrixRun) {
+			defer wg.Done()
+			r.run(executable, inheritedArgs)
+		}(runs[idx])
+	}
+	wg.Wait()
+
+	return printMatrixSummary(runs)
+}
+
+func (r *matrixRun) run(executable string, inheritedArgs []string) {
+	start := time.Now()
+	defer func() {
+		r.duration = time.Since(start)
+	}()
+
+	if err := os.MkdirAll(r.logDir, os.ModePerm); err != nil {
+		r.err = err
+		return
+	}
+
+	args := append([]string{"run"}, inheritedArgs...)
+	args = append(args,
+		"--matrix-index", strconv.Itoa(r.index),
+		"--work-dir", r.workDir,
+		"--log-dir", r.logDir,
+	)
+	if util.BatchMode || parallel {
+		args = append(args, "--batch-mode")
+	}
+
+	c := exec.Command(executable, args...)
+	c.Env = os.Environ()
+	if parallel {
+		// the output of the combinations running in parallel would be interleaved, write them into files instead
+		r.outputFile = filepath.Join(r.logDir, "e2e.log")
+		output, err := os.Create(r.outputFile)
+		if err != nil {
+			r.err = err
+			return
+		}
+		defer output.Close()
+		c.Stdout, c.Stderr = output, output
+	} else {
+		c.Stdout, c.Stderr = os.Stdout, os.Stderr
+	}
+
+	logger.Log.Infof("running matrix combination %d: %s", r.index, r.combination)
+	r.err = c.Run()
+	if r.err != nil {
+		logger.Log.Errorf("matrix combination %d: %s failed: %v", r.index, r.combination, r.err)
+	} else {
+		logger.Log.Infof("matrix combination %d: %s finished successfully", r.index, r.combination)
+	}
+}
+
+// inheritedFlags returns the flags set in the command line, so that each combination runs with the same options.
+func inheritedFlags(cmd *cobra.Command) []string {
+	var args []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if matrixFlags[f.Name] {
+			return
+		}
+		if values, ok := f.Value.(pflag.SliceValue); ok {
+			for _, value := range values.GetSlice() {
+				args = append(args, fmt.Sprintf("--%s=%s", f.Name, value))
+			}
+			return
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", f.Name, f.Value.String()))
+	})
+	return args
+}
+
+func printMatrixSummary(runs []*matrixRun) error {
+	data := pterm.TableData{{"#", "MATRIX", "RESULT", "DURATION", "LOGS"}}
+	failed := 0
+	for _, r := range runs {
+		result := pterm.Green("passed")
+		if r.err != nil {
+			result = pterm.Red("failed")
+			failed++
+		}
+		logs := r.logDir
+		if r.outputFile != "" {
+			logs = r.outputFile
+		}
+		data = append(data, []string{strconv.Itoa(r.index), r.combination.String(), result, r.duration.Round(time.Second).String(), logs})
+	}
+
+	pterm.Info.Prefix = pterm.Prefix{
+		Text:  "SUMMARY",
+		Style: &pterm.ThemeDefault.InfoPrefixStyle,
+	}
+	pterm.Info.Println(fmt.Sprintf("%d passed, %d failed in %d matrix combination(s)", len(runs)-failed, failed, len(runs)))
+	if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+		logger.Log.Warnf("failed to print the matrix summary: %v", err)
+	}
+
+	if failed > 0 {
+		var combinations []string
+		for _, r := range runs {
+			if r.err != nil {
+				combinations = append(combinations, r.combination.String())
+			}
+		}
+		return fmt.Errorf("%d of %d matrix combination(s) failed: [%s]", failed, len(runs), strings.Join(combinations, "], ["))
+	}
+	return nil
+}
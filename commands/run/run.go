@@ -18,6 +18,8 @@
 package run
 
 import (
+	"errors"
+
 	"github.com/apache/skywalking-infra-e2e/commands/cleanup"
 	"github.com/apache/skywalking-infra-e2e/commands/setup"
 	"github.com/apache/skywalking-infra-e2e/commands/trigger"
@@ -27,14 +29,31 @@ import (
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
 
 	"github.com/spf13/cobra"
 )
 
+var parallel bool
+
+func init() {
+	Run.Flags().BoolVarP(&parallel, "parallel", "", false, "run the matrix combinations in parallel, the output of each combination is written into its log directory")
+}
+
 var Run = &cobra.Command{
 	Use:   "run",
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// catch the config mistakes before creating any cluster or container
+		if err := config.Validate(util.CfgFile); err != nil {
+			return err
+		}
+
+		// the combinations are run by separate processes when none of them is selected
+		if errors.Is(config.GlobalConfig.Error, config.ErrMatrixNotSelected) {
+			return runMatrix(cmd)
+		}
+
 		err := runAccordingE2E()
 		if err != nil {
 			return err
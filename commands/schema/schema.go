@@ -0,0 +1,72 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+var (
+	reusingCases bool
+	reusingSteps bool
+	output       string
+)
+
+func init() {
+	Schema.Flags().BoolVarP(&reusingCases, "reusing-cases", "", false, "generate the schema of the reusing cases file instead of the e2e config file")
+	Schema.Flags().BoolVarP(&reusingSteps, "reusing-steps", "", false, "generate the schema of the reusing steps file instead of the e2e config file")
+	Schema.Flags().StringVarP(&output, "output", "o", "", "the file to write the schema to, defaults to stdout")
+}
+
+// Schema prints the JSON Schema of the e2e config file, which could be used by editors to validate the configs.
+var Schema = &cobra.Command{
+	Use:   "schema",
+	Short: "Generate the JSON Schema of the e2e config file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := config.SchemaConfig
+		switch {
+		case reusingCases && reusingSteps:
+			return fmt.Errorf("[Schema] --reusing-cases and --reusing-steps cannot be set at the same time")
+		case reusingCases:
+			target = config.SchemaReusingCases
+		case reusingSteps:
+			target = config.SchemaReusingSteps
+		}
+
+		data, err := json.MarshalIndent(config.GenerateSchema(target), "", "  ")
+		if err != nil {
+			return fmt.Errorf("[Schema] failed to marshal the schema: %v", err)
+		}
+		data = append(data, '\n')
+
+		if output == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(output, data, 0o644); err != nil {
+			return fmt.Errorf("[Schema] failed to write the schema to %s: %v", output, err)
+		}
+		return nil
+	},
+}
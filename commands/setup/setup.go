@@ -20,22 +20,38 @@ package setup
 
 import (
 	"fmt"
+	"os"
 	"sync"
 
+	"github.com/apache/skywalking-infra-e2e/internal/components/environment"
 	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
-	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 
 	"github.com/spf13/cobra"
 )
 
+// RenderOnly prints the rendered manifests of the steps, rather than setting up the environment.
+var RenderOnly bool
+
+func init() {
+	Setup.Flags().BoolVarP(&RenderOnly, "render-only", "", false, "print the final manifests of the steps without setting up the environment")
+}
+
 var Setup = &cobra.Command{
 	Use:   "setup",
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := util.CheckDockerDaemon(); err != nil {
-			return err
+		if RenderOnly {
+			if config.GlobalConfig.Error != nil {
+				return fmt.Errorf("[Setup] %s", config.GlobalConfig.Error)
+			}
+			if err := setup.RenderManifests(config.GlobalConfig.E2EConfig.Setup.Steps, os.Stdout); err != nil {
+				return fmt.Errorf("[Setup] %s", err)
+			}
+			return nil
 		}
 
 		defer setup.CloseLogFollower()
@@ -43,13 +59,17 @@ var Setup = &cobra.Command{
 			return fmt.Errorf("[Setup] %s", err)
 		}
 
-		if config.GlobalConfig.E2EConfig.Setup.Env == constant.Kind && setup.KindShouldWaitSignal() {
+		env, err := environment.New(&config.GlobalConfig.E2EConfig)
+		if err != nil {
+			return fmt.Errorf("[Setup] %s", err)
+		}
+		if waiter, ok := env.(environment.SignalWaiter); ok && waiter.ShouldWaitSignal() {
 			wg := sync.WaitGroup{}
 			wg.Add(1)
 			util.AddShutDownHook(wg.Done)
 			wg.Wait()
 
-			setup.KindCleanNotify()
+			env.Stop()
 		}
 		return nil
 	},
@@ -61,29 +81,32 @@ func DoSetupAccordingE2E() error {
 	}
 
 	e2eConfig := config.GlobalConfig.E2EConfig
+	env, err := environment.New(&e2eConfig)
+	if err != nil {
+		return err
+	}
 
-	setup.InitLogFollower()
-	switch e2eConfig.Setup.Env {
-	case constant.Kind:
-		err := setup.KindSetup(&e2eConfig)
-		if err != nil {
-			return err
+	// save the state even if the setup fails, so the separate commands could inspect and clean it up
+	state.Record(&e2eConfig)
+	defer func() {
+		if err := util.Env.Save(); err != nil {
+			logger.Log.Warnf("failed to save the environment variables, %v", err)
 		}
-	case constant.Compose:
-		err := setup.ComposeSetup(&e2eConfig)
-		if err != nil {
-			return err
+		if err := state.Current.Save(); err != nil {
+			logger.Log.Warnf("failed to save the state of the setup, %v", err)
 		}
-	default:
-		return fmt.Errorf("no such env for setup: [%s]. should use kind or compose instead", e2eConfig.Setup.Env)
-	}
+	}()
 
-	return nil
+	setup.InitLogFollower()
+	return env.Setup()
 }
 
 func DoStopSetup() {
-	// close log follower
-	setup.CloseLogFollower()
-	// notify clean up
-	setup.KindCleanNotify()
+	env, err := environment.New(&config.GlobalConfig.E2EConfig)
+	if err != nil {
+		// close log follower
+		setup.CloseLogFollower()
+		return
+	}
+	env.Stop()
 }
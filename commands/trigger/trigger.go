@@ -25,6 +25,7 @@ import (
 
 	"github.com/apache/skywalking-infra-e2e/internal/components/trigger"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
@@ -33,6 +34,13 @@ import (
 var Trigger = &cobra.Command{
 	Use: "trigger",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if config.GlobalConfig.Error != nil {
+			return config.GlobalConfig.Error
+		}
+		if err := state.Restore(&config.GlobalConfig.E2EConfig); err != nil {
+			return fmt.Errorf("[Trigger] %v", err)
+		}
+
 		action, err := CreateTriggerAction()
 		if err != nil {
 			return fmt.Errorf("[Trigger] %v", err)
@@ -29,6 +29,7 @@ import (
 	"github.com/apache/skywalking-infra-e2e/internal/components/verifier"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 	"github.com/apache/skywalking-infra-e2e/pkg/output"
 )
@@ -37,6 +38,7 @@ var (
 	query    string
 	actual   string
 	expected string
+	lint     bool
 	printer  output.Printer
 )
 
@@ -46,6 +48,7 @@ func init() {
 	Verify.Flags().StringVarP(&expected, "expected", "e", "", "the expected data file, only YAML file format is supported")
 	Verify.Flags().StringVarP(&output.Format, "output", "o", "yaml", "output the verify summary in which format. Currently, only 'yaml' is supported. ")
 	Verify.Flags().BoolVarP(&output.SummaryOnly, "summary-only", "", false, "if true, only 'SUMMARY' part of the verify result will be outputted")
+	Verify.Flags().BoolVarP(&lint, "lint", "", false, "if true, only parse the expected templates to find syntax errors and unknown functions, without verifying")
 }
 
 // Verify verifies that the actual data satisfies the expected data pattern.
@@ -53,6 +56,17 @@ var Verify = &cobra.Command{
 	Use:   "verify",
 	Short: "verify if the actual data match the expected data",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if lint {
+			return lintExpectedTemplates()
+		}
+
+		// the single case could be verified without the config file
+		if config.GlobalConfig.Error == nil {
+			if err := state.Restore(&config.GlobalConfig.E2EConfig); err != nil {
+				return fmt.Errorf("[Verify] %v", err)
+			}
+		}
+
 		if expected != "" {
 			_, err := verifySingleCase(expected, actual, query)
 			return err
@@ -101,6 +115,28 @@ func verifySingleCase(expectedFile, actualFile, query string) (string, error) {
 	return actualData, nil
 }
 
+// lintExpectedTemplates only parses the expected templates, the ones in the config file
+// have been parsed when loading the config.
+func lintExpectedTemplates() error {
+	if expected != "" {
+		expectedData, err := util.ReadFileContent(expected)
+		if err != nil {
+			return fmt.Errorf("failed to read the expected data file: %v", err)
+		}
+		if err := verifier.ParseTemplate(expected, expectedData); err != nil {
+			return fmt.Errorf("failed to parse the expected data file: %v", err)
+		}
+		logger.Log.Infof("expected data file %s is valid", expected)
+		return nil
+	}
+
+	if config.GlobalConfig.Error != nil {
+		return config.GlobalConfig.Error
+	}
+	logger.Log.Infof("all expected data files of %d case(s) are valid", len(config.GlobalConfig.E2EConfig.Verify.Cases))
+	return nil
+}
+
 // concurrentlyVerifySingleCase verifies a single case in concurrency mode,
 // it will call the cancel function if the case fails and the fail-fast is enabled.
 func concurrentlyVerifySingleCase(
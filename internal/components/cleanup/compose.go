@@ -26,6 +26,7 @@ import (
 
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 )
 
@@ -37,9 +38,12 @@ func ComposeCleanUp(conf *config.E2EConfig) error {
 		return fmt.Errorf("no compose config file was provided")
 	}
 	identifier := util.GetIdentity()
+	if state.Current != nil && state.Current.ComposeProject != "" {
+		identifier = state.Current.ComposeProject
+	}
 
 	stack, err := compose.NewDockerComposeWith(
-		compose.WithStackFiles(composeFilePath),
+		compose.WithStackFiles(conf.Setup.GetComposeFiles()...),
 		compose.StackIdentifier(identifier),
 	)
 	if err != nil {
@@ -0,0 +1,69 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/moby/moby/client"
+	"github.com/testcontainers/testcontainers-go"
+
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
+)
+
+// ContainersCleanUp removes the containers, with their volumes, and the networks started by the setup of the containers env.
+func ContainersCleanUp() error {
+	if state.Current == nil || (len(state.Current.Containers) == 0 && len(state.Current.Networks) == 0) {
+		logger.Log.Info("no container to remove")
+		return nil
+	}
+
+	// remove them by the docker client of testcontainers, the same as they are started
+	ctx := context.Background()
+	cli, err := testcontainers.NewDockerClientWithOpts(ctx)
+	if err != nil {
+		return fmt.Errorf("create docker client error: %v", err)
+	}
+	defer func() {
+		if err := cli.Close(); err != nil {
+			logger.Log.Warnf("failed to close docker client: %v", err)
+		}
+	}()
+
+	var errs []string
+	for name, id := range state.Current.Containers {
+		logger.Log.Infof("removing container %s: %s", name, id)
+		if _, err := cli.ContainerRemove(ctx, id, client.ContainerRemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			errs = append(errs, fmt.Sprintf("remove container %s error: %v", name, err))
+		}
+	}
+	for _, network := range state.Current.Networks {
+		logger.Log.Infof("removing network %s", network)
+		if _, err := cli.NetworkRemove(ctx, network, client.NetworkRemoveOptions{}); err != nil {
+			errs = append(errs, fmt.Sprintf("remove network %s error: %v", network, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to clean up containers:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
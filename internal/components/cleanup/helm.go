@@ -0,0 +1,52 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// uninstallHelmRelease uninstalls the release installed by the helm steps, and waits for its resources to be deleted
+// within the timeout.
+func uninstallHelmRelease(release state.HelmRelease, timeout time.Duration) error {
+	args := []string{"uninstall", release.Name, "--wait", "--timeout", timeout.String()}
+	if release.Namespace != "" {
+		args = append(args, "--namespace", release.Namespace)
+	}
+	logger.Log.Infof("uninstalling helm release %s", release.Name)
+	// wait a little longer than helm, to get its own timeout error
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+10*time.Second)
+	defer cancel()
+	if _, stderr, err := util.RunHelm(ctx, args...); err != nil {
+		// it's uninstalled by the previous cleanup, or along with its namespace
+		if strings.Contains(stderr, "not found") {
+			logger.Log.Infof("helm release %s is not found", release.Name)
+			return nil
+		}
+		return fmt.Errorf("%v, stderr: %s", err, stderr)
+	}
+	return nil
+}
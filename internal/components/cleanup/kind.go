@@ -29,6 +29,7 @@ import (
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 )
 
@@ -47,7 +48,10 @@ func KindCleanUp(e2eConfig *config.E2EConfig) error {
 	}
 	logger.Log.Info("delete kind cluster succeeded")
 
-	kubeConfigPath := constant.K8sClusterConfigFilePath
+	kubeConfigPath := util.GetK8sClusterConfigFilePath()
+	if state.Current != nil && state.Current.Kubeconfig != "" {
+		kubeConfigPath = state.Current.Kubeconfig
+	}
 	logger.Log.Infof("deleting k8s cluster config file:%s", kubeConfigPath)
 	err := os.Remove(kubeConfigPath)
 	if err != nil {
@@ -62,6 +66,9 @@ func cleanKindCluster(kindConfigFilePath string) (err error) {
 	if err != nil {
 		return err
 	}
+	if state.Current != nil && state.Current.KindCluster != "" {
+		clusterName = state.Current.KindCluster
+	}
 
 	args := []string{"delete", "cluster", "--name", clusterName}
 
@@ -0,0 +1,101 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cleanup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// KubernetesCleanUp deletes the objects created by the manifest steps, and uninstalls the helm releases,
+// in the reverse order they are created. It's only needed by the existing cluster of `setup.kubeconfig`,
+// as the kind cluster is deleted with all of them.
+func KubernetesCleanUp(e2eConfig *config.E2EConfig) error {
+	if state.Current == nil || len(state.Current.Objects)+len(state.Current.HelmReleases) == 0 {
+		logger.Log.Info("no kubernetes object or helm release to delete")
+		return nil
+	}
+
+	type created struct {
+		order  int
+		name   string
+		delete func(timeout time.Duration) error
+	}
+	var all []created
+	for _, release := range state.Current.HelmReleases {
+		all = append(all, created{
+			order:  release.Order,
+			name:   fmt.Sprintf("helm release %s/%s", release.Namespace, release.Name),
+			delete: func(timeout time.Duration) error { return uninstallHelmRelease(release, timeout) },
+		})
+	}
+	if len(state.Current.Objects) > 0 {
+		cluster, err := util.ConnectToK8sCluster(e2eConfig.Setup.GetKubeconfig())
+		if err != nil {
+			return fmt.Errorf("connect to k8s cluster error: %v", err)
+		}
+		for _, object := range state.Current.Objects {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion(object.APIVersion)
+			obj.SetKind(object.Kind)
+			obj.SetNamespace(object.Namespace)
+			obj.SetName(object.Name)
+			all = append(all, created{
+				order: object.Order,
+				name:  fmt.Sprintf("%s %s", object.Kind, strings.TrimPrefix(object.Namespace+"/"+object.Name, "/")),
+				delete: func(timeout time.Duration) error {
+					return util.DeleteObjectAndWait(cluster, obj, timeout)
+				},
+			})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].order > all[j].order })
+
+	// all the deletions share the timeout of the setup
+	timeout := e2eConfig.Setup.GetTimeout()
+	deadline := time.Now().Add(timeout)
+	var errs []string
+	for _, c := range all {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			// keep it in the state, so it could be deleted by retrying the cleanup
+			errs = append(errs, fmt.Sprintf("%s: not attempted, timed out after %s", c.name, timeout))
+			continue
+		}
+		logger.Log.Infof("deleting %s", c.name)
+		if err := c.delete(remaining); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", c.name, err))
+			continue
+		}
+		logger.Log.Infof("deleted %s", c.name)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
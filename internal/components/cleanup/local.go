@@ -0,0 +1,65 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// processGracePeriod is the time the background processes have to exit after being terminated.
+const processGracePeriod = 10 * time.Second
+
+// LocalCleanUp kills the process groups of the background steps started by the setup.
+func LocalCleanUp() error {
+	if state.Current == nil || len(state.Current.Processes) == 0 {
+		logger.Log.Info("no background process to kill")
+		return nil
+	}
+
+	var errs []string
+	for _, process := range state.Current.Processes {
+		// the pid of a group leader is not reused while the group exists, so the group is still killed if the leader exited
+		started, err := util.ProcessStartTime(process.PID)
+		if err != nil && !errors.Is(err, os.ErrProcessDone) {
+			errs = append(errs, fmt.Sprintf("process group %d: %v", process.PID, err))
+			continue
+		}
+		if err == nil && process.StartTime != "" && started != process.StartTime {
+			logger.Log.Warnf("process %d is not the background process started by the setup, skip killing it", process.PID)
+			continue
+		}
+
+		logger.Log.Infof("killing background process group %d", process.PID)
+		if err := util.KillProcessGroup(process.PID, processGracePeriod); err != nil {
+			errs = append(errs, fmt.Sprintf("process group %d: %v", process.PID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to kill background processes:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
@@ -21,13 +21,13 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/apache/skywalking-infra-e2e/internal/components/environment"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
-	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 )
 
 // DoCollect collects files from pods/containers based on the collect config.
-// It dispatches to Kind or Compose collector based on setup.env.
+// It dispatches to the environment of setup.env, such as KindCollect and ComposeCollect.
 // Errors are logged but tolerated — partial setup may leave some targets unreachable.
 func DoCollect(e2eConfig *config.E2EConfig) error {
 	collectCfg := &e2eConfig.Cleanup.Collect
@@ -47,12 +47,9 @@ func DoCollect(e2eConfig *config.E2EConfig) error {
 
 	logger.Log.Infof("collecting files to %s", collectCfg.OutputDir)
 
-	switch e2eConfig.Setup.Env {
-	case constant.Kind:
-		return kindCollect(e2eConfig, collectCfg)
-	case constant.Compose:
-		return composeCollect(e2eConfig, collectCfg)
-	default:
-		return fmt.Errorf("unsupported env for collect: %s", e2eConfig.Setup.Env)
+	env, err := environment.New(e2eConfig)
+	if err != nil {
+		return err
 	}
+	return env.Collect(collectCfg)
 }
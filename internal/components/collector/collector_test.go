@@ -18,6 +18,9 @@
 package collector
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/apache/skywalking-infra-e2e/internal/config"
@@ -192,11 +195,44 @@ func TestExpandContainerGlob_NoGlob(t *testing.T) {
 	}
 }
 
+func TestComposeFlags(t *testing.T) {
+	setup := &config.Setup{
+		File:    "/work dir/docker-compose.yml",
+		Compose: config.ComposeSetup{Files: []string{"/work dir/$(override).yml"}, Profiles: []string{"debug"}},
+	}
+	want := []string{"-f", "/work dir/docker-compose.yml", "-f", "/work dir/$(override).yml", "--profile", "debug"}
+	if got := composeFlags(setup); !reflect.DeepEqual(got, want) {
+		t.Errorf("composeFlags() = %q, want %q", got, want)
+	}
+}
+
 func TestComposeCollectItem_NoService(t *testing.T) {
-	err := composeCollectItem("/fake/compose.yml", "test-project", t.TempDir(), &config.CollectItem{
+	err := composeCollectItem([]string{"-f", "/fake/compose.yml"}, "test-project", t.TempDir(), &config.CollectItem{
 		Paths: []string{"/tmp"},
 	})
 	if err == nil {
 		t.Error("composeCollectItem with empty service should return error")
 	}
 }
+
+func TestCopyPath(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "logs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "logs", "app.log"), []byte("started"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "collected")
+	if err := copyPath(src, dest); err != nil {
+		t.Fatalf("copyPath() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dest, "logs", "app.log"))
+	if err != nil {
+		t.Fatalf("read copied file: %v", err)
+	}
+	if string(content) != "started" {
+		t.Errorf("copied content = %q, want %q", content, "started")
+	}
+}
@@ -18,6 +18,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -26,19 +27,24 @@ import (
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 )
 
-func composeCollect(e2eConfig *config.E2EConfig, collectCfg *config.CollectConfig) error {
-	composeFile := e2eConfig.Setup.GetFile()
-	if composeFile == "" {
+// ComposeCollect collects the files from the containers of the compose project.
+func ComposeCollect(e2eConfig *config.E2EConfig, collectCfg *config.CollectConfig) error {
+	if e2eConfig.Setup.GetFile() == "" {
 		return fmt.Errorf("compose file not configured in setup.file")
 	}
+	composeFlags := composeFlags(&e2eConfig.Setup)
 	projectName := util.GetIdentity()
+	if state.Current != nil && state.Current.ComposeProject != "" {
+		projectName = state.Current.ComposeProject
+	}
 
 	var errs []string
 	for _, item := range collectCfg.Items {
-		if err := composeCollectItem(composeFile, projectName, collectCfg.OutputDir, &item); err != nil {
+		if err := composeCollectItem(composeFlags, projectName, collectCfg.OutputDir, &item); err != nil {
 			errs = append(errs, fmt.Sprintf("collect item error: %v", err))
 			logger.Log.Warnf("failed to collect item for service %s: %v", item.Service, err)
 		}
@@ -50,22 +56,26 @@ func composeCollect(e2eConfig *config.E2EConfig, collectCfg *config.CollectConfi
 	return nil
 }
 
-func composeCollectItem(composeFile, projectName, outputDir string, item *config.CollectItem) error {
+func composeCollectItem(composeFlags []string, projectName, outputDir string, item *config.CollectItem) error {
 	if item.Service == "" {
 		return fmt.Errorf("service name is required for compose collect items")
 	}
 
-	serviceDir := filepath.Join(outputDir, item.Service)
-	if err := os.MkdirAll(serviceDir, os.ModePerm); err != nil {
-		return err
-	}
-
 	// Find container ID using the compose file and project name that setup used
-	containerID, err := findComposeContainer(composeFile, projectName, item.Service)
+	containerID, err := findComposeContainer(composeFlags, projectName, item.Service)
 	if err != nil {
 		logger.Log.Warnf("failed to find container for service %s: container may not be running yet. %v", item.Service, err)
 		return fmt.Errorf("failed to find container for service %s: %v", item.Service, err)
 	}
+	return collectContainerItem(outputDir, containerID, item)
+}
+
+// collectContainerItem collects the inspect output and the paths of the item from the container.
+func collectContainerItem(outputDir, containerID string, item *config.CollectItem) error {
+	serviceDir := filepath.Join(outputDir, item.Service)
+	if err := os.MkdirAll(serviceDir, os.ModePerm); err != nil {
+		return err
+	}
 
 	// Collect docker inspect output
 	if err := collectContainerInspect(outputDir, item.Service, containerID); err != nil {
@@ -93,18 +103,31 @@ func composeCollectItem(composeFile, projectName, outputDir string, item *config
 	return nil
 }
 
+// composeFlags returns the `-f` and `--profile` flags of the compose files and profiles that setup uses.
+func composeFlags(setup *config.Setup) []string {
+	var flags []string
+	for _, file := range setup.GetComposeFiles() {
+		flags = append(flags, "-f", file)
+	}
+	for _, profile := range setup.Compose.Profiles {
+		flags = append(flags, "--profile", profile)
+	}
+	return flags
+}
+
 // findComposeContainer locates the container ID for a service using the same
-// compose file and project name that setup/cleanup use.
-func findComposeContainer(composeFile, projectName, service string) (string, error) {
-	cmd := fmt.Sprintf("%s -f %s -p %s ps -q %s", constant.ComposeCommand, composeFile, projectName, service)
-	stdout, stderr, err := util.ExecuteCommand(cmd)
+// compose files, profiles and project name that setup/cleanup use.
+// The arguments are passed without the shell, so the paths with spaces or shell characters are kept as they are.
+func findComposeContainer(composeFlags []string, projectName, service string) (string, error) {
+	args := append(composeFlags[:len(composeFlags):len(composeFlags)], "-p", projectName, "ps", "-q", service)
+	stdout, stderr, err := util.RunCommand(context.Background(), constant.ComposeCommand, args...)
 	if err != nil {
 		return "", fmt.Errorf("docker compose ps failed: %v, stderr: %s", err, stderr)
 	}
 
 	containerID := strings.TrimSpace(stdout)
 	if containerID == "" {
-		return "", fmt.Errorf("no container found for service %s (project: %s, file: %s)", service, projectName, composeFile)
+		return "", fmt.Errorf("no container found for service %s (project: %s, flags: %q)", service, projectName, composeFlags)
 	}
 	return containerID, nil
 }
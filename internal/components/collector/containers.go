@@ -0,0 +1,53 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// ContainersCollect collects the files from the containers of the containers env, the service of an item is the container name.
+func ContainersCollect(collectCfg *config.CollectConfig) error {
+	var errs []string
+	for _, item := range collectCfg.Items {
+		if item.Service == "" {
+			errs = append(errs, "collect item error: service name is required for containers collect items")
+			continue
+		}
+		// the container is named after the identity by the setup, if it's not recorded
+		containerID := fmt.Sprintf("%s-%s", util.GetIdentity(), item.Service)
+		if state.Current != nil && state.Current.Containers[item.Service] != "" {
+			containerID = state.Current.Containers[item.Service]
+		}
+		if err := collectContainerItem(collectCfg.OutputDir, containerID, &item); err != nil {
+			errs = append(errs, fmt.Sprintf("collect item error: %v", err))
+			logger.Log.Warnf("failed to collect item for container %s: %v", item.Service, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("some collect items failed:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
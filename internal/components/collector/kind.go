@@ -25,15 +25,15 @@ import (
 	"strings"
 
 	"github.com/apache/skywalking-infra-e2e/internal/config"
-	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 )
 
-func kindCollect(e2eConfig *config.E2EConfig, collectCfg *config.CollectConfig) error {
+// KindCollect collects the files from the pods of the kind cluster.
+func KindCollect(e2eConfig *config.E2EConfig, collectCfg *config.CollectConfig) error {
 	kubeConfigPath := e2eConfig.Setup.GetKubeconfig()
 	if kubeConfigPath == "" {
-		kubeConfigPath = constant.K8sClusterConfigFilePath
+		kubeConfigPath = util.GetK8sClusterConfigFilePath()
 	}
 
 	var errs []string
@@ -0,0 +1,132 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package collector
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// localDir is the directory under the output dir the files on the host are collected to.
+const localDir = "local"
+
+// LocalCollect collects the files on the host for the local env.
+func LocalCollect(collectCfg *config.CollectConfig) error {
+	var errs []string
+	for _, item := range collectCfg.Items {
+		for _, p := range item.Paths {
+			paths, err := filepath.Glob(util.ExpandFilePath(util.ExpandEnv(p)))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("path %s: %v", p, err))
+				continue
+			}
+			if len(paths) == 0 {
+				logger.Log.Warnf("path %s matched no files", p)
+				errs = append(errs, fmt.Sprintf("path %s: no such file", p))
+				continue
+			}
+			for _, expanded := range paths {
+				if err := collectLocalFile(collectCfg.OutputDir, expanded); err != nil {
+					errs = append(errs, fmt.Sprintf("path %s: %v", expanded, err))
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("some files failed to collect:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func collectLocalFile(outputDir, srcPath string) error {
+	absPath, err := filepath.Abs(srcPath)
+	if err != nil {
+		return err
+	}
+	// Preserve the full source path under the local directory, the same as the files in containers.
+	destPath := filepath.Join(outputDir, localDir, strings.TrimLeft(absPath, string(filepath.Separator)))
+	if err := os.MkdirAll(filepath.Dir(destPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := copyPath(absPath, destPath); err != nil {
+		return fmt.Errorf("copy failed: %v", err)
+	}
+	logger.Log.Infof("collected %s to %s", absPath, destPath)
+	return nil
+}
+
+// copyPath copies the file or the directory recursively like `cp -R`, the symbolic links are copied as they are.
+func copyPath(srcPath, destPath string) error {
+	return filepath.WalkDir(srcPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcPath, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(destPath, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(dest, info.Mode().Perm()|0o700)
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, dest)
+		case entry.Type().IsRegular():
+			return copyFile(path, dest, info.Mode().Perm())
+		default:
+			logger.Log.Warnf("skip collecting %s, which is not a regular file", path)
+			return nil
+		}
+	})
+}
+
+func copyFile(srcPath, destPath string, perm fs.FileMode) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dest, src); err != nil {
+		dest.Close()
+		return err
+	}
+	return dest.Close()
+}
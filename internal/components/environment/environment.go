@@ -0,0 +1,88 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package environment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+// Environment is where the tested services run, selected by `setup.env` in the config file.
+type Environment interface {
+	// Setup sets up the environment and runs the setup steps in it.
+	Setup() error
+	// Stop stops the background tasks of the setup, such as following the logs and forwarding the ports.
+	Stop()
+	// Cleanup destroys the environment.
+	Cleanup() error
+	// Collect copies the files of the collect items out of the environment.
+	Collect(collectCfg *config.CollectConfig) error
+	// ExportedEnv returns the variables exported by the environment, such as the hosts and ports of the services.
+	ExportedEnv() map[string]string
+	// LogSources returns the log files the outputs of the environment are written to.
+	LogSources() []string
+}
+
+// SignalWaiter is implemented by the environments that need `e2e setup` to keep running until interrupted,
+// such as forwarding the ports to the host.
+type SignalWaiter interface {
+	ShouldWaitSignal() bool
+}
+
+// Factory creates the environment according to the config.
+type Factory func(conf *config.E2EConfig) Environment
+
+var (
+	factories     = make(map[string]Factory)
+	factoriesLock sync.RWMutex
+)
+
+// Register registers the environment by the name used in `setup.env`, the registered one is replaced.
+func Register(name string, factory Factory) {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	factories[name] = factory
+	config.RegisterEnv(name)
+}
+
+// New creates the environment of `setup.env` in the config.
+func New(conf *config.E2EConfig) (Environment, error) {
+	factoriesLock.RLock()
+	factory, ok := factories[conf.Setup.Env]
+	factoriesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no such env: [%s], should be one of %s", conf.Setup.Env, strings.Join(Names(), ", "))
+	}
+	return factory(conf), nil
+}
+
+// Names returns the names of the registered environments.
+func Names() []string {
+	factoriesLock.RLock()
+	defer factoriesLock.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package environment
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+type fakeEnvironment struct {
+	conf  *config.E2EConfig
+	setUp bool
+}
+
+func (f *fakeEnvironment) Setup() error {
+	f.setUp = true
+	return nil
+}
+
+func (f *fakeEnvironment) Stop() {}
+
+func (f *fakeEnvironment) Cleanup() error {
+	return nil
+}
+
+func (f *fakeEnvironment) Collect(*config.CollectConfig) error {
+	return nil
+}
+
+func (f *fakeEnvironment) ExportedEnv() map[string]string {
+	return map[string]string{"fake_host": "127.0.0.1"}
+}
+
+func (f *fakeEnvironment) LogSources() []string {
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	Register("fake", func(conf *config.E2EConfig) Environment {
+		return &fakeEnvironment{conf: conf}
+	})
+
+	conf := &config.E2EConfig{Setup: config.Setup{Env: "fake"}}
+	env, err := New(conf)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	fake, ok := env.(*fakeEnvironment)
+	if !ok || fake.conf != conf {
+		t.Fatalf("New() = %#v, want the fake environment of the config", env)
+	}
+	if err := env.Setup(); err != nil || !fake.setUp {
+		t.Errorf("Setup() error = %v, set up = %v", err, fake.setUp)
+	}
+	if got := env.ExportedEnv(); !reflect.DeepEqual(got, map[string]string{"fake_host": "127.0.0.1"}) {
+		t.Errorf("ExportedEnv() = %v", got)
+	}
+	if names := Names(); !reflect.DeepEqual(names, []string{"fake"}) {
+		t.Errorf("Names() = %v, want [fake]", names)
+	}
+
+	_, err = New(&config.E2EConfig{Setup: config.Setup{Env: "docker"}})
+	if err == nil || !strings.Contains(err.Error(), "no such env: [docker], should be one of fake") {
+		t.Errorf("New() error = %v, want no such env", err)
+	}
+
+	// the registered environments are accepted by the validation
+	schema := config.GenerateSchema(config.SchemaConfig)
+	if enum := schema.Properties["setup"].Properties["env"].Enum; enum[len(enum)-1] != "fake" {
+		t.Errorf("setup.env enum = %v, want fake registered", enum)
+	}
+}
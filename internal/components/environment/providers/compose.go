@@ -0,0 +1,66 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package providers
+
+import (
+	"github.com/apache/skywalking-infra-e2e/internal/components/cleanup"
+	"github.com/apache/skywalking-infra-e2e/internal/components/collector"
+	"github.com/apache/skywalking-infra-e2e/internal/components/environment"
+	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func init() {
+	environment.Register(constant.Compose, func(conf *config.E2EConfig) environment.Environment {
+		return &composeEnvironment{conf: conf}
+	})
+}
+
+// composeEnvironment starts the services of the docker compose file.
+type composeEnvironment struct {
+	conf *config.E2EConfig
+}
+
+func (c *composeEnvironment) Setup() error {
+	if err := util.CheckDockerDaemon(); err != nil {
+		return err
+	}
+	return setup.ComposeSetup(c.conf)
+}
+
+func (c *composeEnvironment) Stop() {
+	setup.CloseLogFollower()
+}
+
+func (c *composeEnvironment) Cleanup() error {
+	return cleanup.ComposeCleanUp(c.conf)
+}
+
+func (c *composeEnvironment) Collect(collectCfg *config.CollectConfig) error {
+	return collector.ComposeCollect(c.conf, collectCfg)
+}
+
+func (c *composeEnvironment) ExportedEnv() map[string]string {
+	return setup.ExportedEnv()
+}
+
+func (c *composeEnvironment) LogSources() []string {
+	return setup.LogSources()
+}
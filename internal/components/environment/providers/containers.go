@@ -0,0 +1,69 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package providers
+
+import (
+	"github.com/apache/skywalking-infra-e2e/internal/components/cleanup"
+	"github.com/apache/skywalking-infra-e2e/internal/components/collector"
+	"github.com/apache/skywalking-infra-e2e/internal/components/environment"
+	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func init() {
+	environment.Register(constant.Containers, func(conf *config.E2EConfig) environment.Environment {
+		return &containersEnvironment{conf: conf}
+	})
+}
+
+// containersEnvironment starts the containers declared in the config, without a docker compose file.
+type containersEnvironment struct {
+	conf *config.E2EConfig
+}
+
+func (c *containersEnvironment) Setup() error {
+	if err := util.CheckDockerDaemon(); err != nil {
+		return err
+	}
+	return setup.ContainersSetup(c.conf)
+}
+
+func (c *containersEnvironment) Stop() {
+	setup.CloseLogFollower()
+}
+
+func (c *containersEnvironment) Cleanup() error {
+	err := cleanup.ContainersCleanUp()
+	// the logs of the removed containers are complete, close their log files
+	setup.CloseLogFollower()
+	return err
+}
+
+func (c *containersEnvironment) Collect(collectCfg *config.CollectConfig) error {
+	return collector.ContainersCollect(collectCfg)
+}
+
+func (c *containersEnvironment) ExportedEnv() map[string]string {
+	return setup.ExportedEnv()
+}
+
+func (c *containersEnvironment) LogSources() []string {
+	return setup.LogSources()
+}
@@ -0,0 +1,75 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package providers
+
+import (
+	"github.com/apache/skywalking-infra-e2e/internal/components/cleanup"
+	"github.com/apache/skywalking-infra-e2e/internal/components/collector"
+	"github.com/apache/skywalking-infra-e2e/internal/components/environment"
+	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func init() {
+	environment.Register(constant.Kind, func(conf *config.E2EConfig) environment.Environment {
+		return &kindEnvironment{conf: conf}
+	})
+}
+
+// kindEnvironment creates a kind cluster, or uses the existing cluster of `setup.kubeconfig`.
+type kindEnvironment struct {
+	conf *config.E2EConfig
+}
+
+func (k *kindEnvironment) Setup() error {
+	if err := util.CheckDockerDaemon(); err != nil {
+		return err
+	}
+	return setup.KindSetup(k.conf)
+}
+
+func (k *kindEnvironment) Stop() {
+	setup.CloseLogFollower()
+	setup.KindCleanNotify()
+}
+
+func (k *kindEnvironment) ShouldWaitSignal() bool {
+	return setup.KindShouldWaitSignal()
+}
+
+func (k *kindEnvironment) Cleanup() error {
+	// if there is an existing kubernetes cluster, don't delete the kind cluster, but what the setup created in it.
+	if k.conf.Setup.GetKubeconfig() != "" {
+		return cleanup.KubernetesCleanUp(k.conf)
+	}
+	return cleanup.KindCleanUp(k.conf)
+}
+
+func (k *kindEnvironment) Collect(collectCfg *config.CollectConfig) error {
+	return collector.KindCollect(k.conf, collectCfg)
+}
+
+func (k *kindEnvironment) ExportedEnv() map[string]string {
+	return setup.ExportedEnv()
+}
+
+func (k *kindEnvironment) LogSources() []string {
+	return setup.LogSources()
+}
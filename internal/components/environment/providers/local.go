@@ -0,0 +1,62 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package providers
+
+import (
+	"github.com/apache/skywalking-infra-e2e/internal/components/cleanup"
+	"github.com/apache/skywalking-infra-e2e/internal/components/collector"
+	"github.com/apache/skywalking-infra-e2e/internal/components/environment"
+	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+func init() {
+	environment.Register(constant.Local, func(conf *config.E2EConfig) environment.Environment {
+		return &localEnvironment{conf: conf}
+	})
+}
+
+// localEnvironment runs the steps on the host, without docker or kubernetes.
+type localEnvironment struct {
+	conf *config.E2EConfig
+}
+
+func (l *localEnvironment) Setup() error {
+	return setup.LocalSetup(l.conf)
+}
+
+func (l *localEnvironment) Stop() {
+	setup.CloseLogFollower()
+}
+
+func (l *localEnvironment) Cleanup() error {
+	return cleanup.LocalCleanUp()
+}
+
+func (l *localEnvironment) Collect(collectCfg *config.CollectConfig) error {
+	return collector.LocalCollect(collectCfg)
+}
+
+func (l *localEnvironment) ExportedEnv() map[string]string {
+	return setup.ExportedEnv()
+}
+
+func (l *localEnvironment) LogSources() []string {
+	return setup.LogSources()
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/apache/skywalking-infra-e2e/internal/config"
@@ -31,55 +32,14 @@ import (
 
 var (
 	logFollower *util.ResourceLogFollower
-)
-
-func RunStepsAndWait(steps []config.Step, waitTimeout time.Duration, k8sCluster *util.K8sClusterInfo) error {
-	logger.Log.Debugf("wait timeout is %v", waitTimeout.String())
-
-	// record time now
-	timeNow := time.Now()
-
-	for _, step := range steps {
-		logger.Log.Infof("processing setup step [%s]", step.Name)
-
-		if step.Path != "" && step.Command == "" {
-			if k8sCluster == nil {
-				return fmt.Errorf("not support path")
-			}
-			manifest := config.Manifest{
-				Path:  step.Path,
-				Waits: step.Waits,
-			}
-			err := createManifestAndWait(k8sCluster, manifest, waitTimeout)
-			if err != nil {
-				return err
-			}
-		} else if step.Command != "" && step.Path == "" {
-			command := config.Run{
-				Command: step.Command,
-				Waits:   step.Waits,
-			}
-
-			err := RunCommandsAndWait(command, waitTimeout, k8sCluster)
-			if err != nil {
-				return err
-			}
-		} else {
-			return fmt.Errorf("step parameter error, one Path or one Command should be specified, but got %+v", step)
-		}
-
-		waitTimeout = NewTimeout(timeNow, waitTimeout)
-		timeNow = time.Now()
 
-		if waitTimeout <= 0 {
-			return fmt.Errorf("setup timeout")
-		}
-	}
-	return nil
-}
+	// exportedEnv are the variables exported by the environment, rather than the steps.
+	exportedEnv     = make(map[string]string)
+	exportedEnvLock sync.Mutex
+)
 
 // createManifestAndWait creates manifests in k8s cluster and concurrent waits according to the manifests' wait conditions.
-func createManifestAndWait(c *util.K8sClusterInfo, manifest config.Manifest, timeout time.Duration) error {
+func createManifestAndWait(ctx context.Context, c *util.K8sClusterInfo, manifest config.Manifest, timeout time.Duration) error {
 	waitSet := util.NewWaitSet(timeout)
 
 	waits := manifest.Waits
@@ -98,13 +58,8 @@ func createManifestAndWait(c *util.K8sClusterInfo, manifest config.Manifest, tim
 		wait := waits[idx]
 		logger.Log.Infof("waiting for %+v", wait)
 
-		options, err := getWaitOptions(c, &wait)
-		if err != nil {
-			return err
-		}
-
 		waitSet.WaitGroup.Add(1)
-		go concurrentlyWait(&wait, options, waitSet)
+		go concurrentlyWait(ctx, c, &wait, waitSet)
 	}
 
 	go func() {
@@ -120,13 +75,15 @@ func createManifestAndWait(c *util.K8sClusterInfo, manifest config.Manifest, tim
 		return err
 	case <-time.After(waitSet.Timeout):
 		return fmt.Errorf("wait for manifest ready timeout after %d seconds", int(timeout.Seconds()))
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
 	return nil
 }
 
 // RunCommandsAndWait Concurrently run commands and wait for conditions.
-func RunCommandsAndWait(run config.Run, timeout time.Duration, cluster *util.K8sClusterInfo) error {
+func RunCommandsAndWait(ctx context.Context, run config.Run, timeout time.Duration, cluster *util.K8sClusterInfo) error {
 	waitSet := util.NewWaitSet(timeout)
 
 	commands := run.Command
@@ -135,7 +92,7 @@ func RunCommandsAndWait(run config.Run, timeout time.Duration, cluster *util.K8s
 	}
 
 	waitSet.WaitGroup.Add(1)
-	go executeCommandsAndWait(commands, run.Waits, waitSet, cluster)
+	go executeCommandsAndWait(ctx, commands, run.Waits, waitSet, cluster)
 
 	go func() {
 		waitSet.WaitGroup.Wait()
@@ -150,20 +107,24 @@ func RunCommandsAndWait(run config.Run, timeout time.Duration, cluster *util.K8s
 		return err
 	case <-time.After(waitSet.Timeout):
 		return fmt.Errorf("wait for commands run timeout after %d seconds", int(timeout.Seconds()))
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
 	return nil
 }
 
-func executeCommandsAndWait(commands string, waits []config.Wait, waitSet *util.WaitSet, cluster *util.K8sClusterInfo) {
+func executeCommandsAndWait(ctx context.Context, commands string, waits []config.Wait, waitSet *util.WaitSet,
+	cluster *util.K8sClusterInfo) {
 	defer waitSet.WaitGroup.Done()
 
 	// executes commands
 	logger.Log.Infof("executing commands [%s]", strings.ReplaceAll(commands, "\n", "\\n"))
-	result, stderr, err := util.ExecuteCommand(commands)
+	result, stderr, err := util.ExecuteCommandContext(ctx, commands)
 	if err != nil {
 		err = fmt.Errorf("commands: [%s] runs error: %s", strings.ReplaceAll(commands, "\n", "\\n"), stderr)
 		waitSet.ErrChan <- err
+		return
 	}
 	logger.Log.Infof("executed commands [%s], result: %s", strings.ReplaceAll(commands, "\n", "\\n"), result)
 
@@ -172,13 +133,7 @@ func executeCommandsAndWait(commands string, waits []config.Wait, waitSet *util.
 		wait := waits[idx]
 		logger.Log.Infof("waiting for %+v", wait)
 
-		options, err := getWaitOptions(cluster, &wait)
-		if err != nil {
-			err = fmt.Errorf("commands: [%s] get wait options error: %s", commands, err)
-			waitSet.ErrChan <- err
-		}
-
-		err = options.RunWait()
+		err = runWait(ctx, cluster, &wait, waitSet.Timeout)
 		if err != nil {
 			err = fmt.Errorf("commands: [%s] waits error: %s", commands, err)
 			waitSet.ErrChan <- err
@@ -195,6 +150,35 @@ func NewTimeout(timeBefore time.Time, timeout time.Duration) time.Duration {
 	return newTimeout
 }
 
+// exportEnv exports the variable of the environment, such as the host and port of a service, into the environment store.
+func exportEnv(key, value string) {
+	exportedEnvLock.Lock()
+	exportedEnv[key] = value
+	exportedEnvLock.Unlock()
+
+	util.Env.Set(key, value)
+	logger.Log.Infof("export %s=%s", key, value)
+}
+
+// ExportedEnv returns the variables exported by the environment.
+func ExportedEnv() map[string]string {
+	exportedEnvLock.Lock()
+	defer exportedEnvLock.Unlock()
+	vars := make(map[string]string, len(exportedEnv))
+	for key, value := range exportedEnv {
+		vars[key] = value
+	}
+	return vars
+}
+
+// LogSources returns the log files the outputs of the environment are written to.
+func LogSources() []string {
+	if logFollower == nil {
+		return nil
+	}
+	return logFollower.LogFiles()
+}
+
 func InitLogFollower() {
 	logFollower = util.NewResourceLogFollower(context.Background(), util.LogDir)
 }
@@ -20,6 +20,7 @@ package setup
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -27,16 +28,22 @@ import (
 	"strings"
 	"time"
 
+	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/compose"
-	"gopkg.in/yaml.v2"
 
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 )
 
+// serviceStateInterval is the interval between the checks of the service containers' state.
+const serviceStateInterval = time.Second
+
 // ComposeSetup sets up environment according to e2e.yaml.
 func ComposeSetup(e2eConfig *config.E2EConfig) error {
 	composeConfigPath := e2eConfig.Setup.GetFile()
@@ -44,154 +51,176 @@ func ComposeSetup(e2eConfig *config.E2EConfig) error {
 		return fmt.Errorf("no compose config file was provided")
 	}
 
-	// parse compose file to extract services and their ports
-	services, err := parseComposeFile(composeConfigPath)
-	if err != nil {
-		return fmt.Errorf("parse compose file error: %v", err)
-	}
-
 	// load environment variables from env file
 	if e2eConfig.Setup.InitSystemEnvironment != "" {
 		profilePath := util.ResolveAbs(e2eConfig.Setup.InitSystemEnvironment)
 		util.ExportEnvVars(profilePath)
 	}
 
-	// Disable Ryuk reaper when cleanup.on is "never" so containers survive process exit.
+	// Disable Ryuk reaper when cleanup.on is "never" so containers survive process exit,
+	// it's read by testcontainers from the e2e process rather than the environment store.
 	if e2eConfig.Cleanup.On == constant.CleanUpNever {
 		if err := os.Setenv("TESTCONTAINERS_RYUK_DISABLED", "true"); err != nil {
 			return fmt.Errorf("failed to disable Ryuk reaper: %v", err)
 		}
 	}
 
-	// create compose stack
+	// load the compose project to find the services and their ports, after the variables are exported
+	ctx := context.Background()
 	identifier := util.GetIdentity()
+	files, profiles := e2eConfig.Setup.GetComposeFiles(), e2eConfig.Setup.Compose.Profiles
+	project, err := loadComposeProject(ctx, files, profiles, identifier)
+	if err != nil {
+		return fmt.Errorf("load compose project error: %v", err)
+	}
+	for _, name := range e2eConfig.Setup.Compose.OneShot {
+		if _, ok := project.Services[name]; !ok {
+			return fmt.Errorf("one-shot service %s is not found in the enabled services %v", name, project.ServiceNames())
+		}
+	}
+
+	// create compose stack
+	state.Update(func(s *state.State) { s.ComposeProject = identifier })
 	stack, err := compose.NewDockerComposeWith(
-		compose.WithStackFiles(composeConfigPath),
+		compose.WithStackFiles(files...),
 		compose.StackIdentifier(identifier),
+		compose.WithProfiles(profiles...),
 	)
 	if err != nil {
 		return fmt.Errorf("create compose stack error: %v", err)
 	}
 
-	// pass current environment to compose
+	// pass current environment and the exported variables to compose
 	stack.WithOsEnv()
+	stack.WithEnv(util.Env.Vars())
 
 	// bring up the compose stack (non-blocking, like docker compose up -d)
-	ctx := context.Background()
 	if err := stack.Up(ctx); err != nil {
 		return fmt.Errorf("compose up error: %v", err)
 	}
 
-	// wait for ports, expose env vars and logs for each service
-	waitTimeout := e2eConfig.Setup.GetTimeout()
-	for _, svc := range services {
-		ctr, err := stack.ServiceContainer(ctx, svc.name)
-		if err != nil {
-			logger.Log.Warnf("could not get container for service %s: %v", svc.name, err)
-			continue
-		}
-
-		// start log streaming for all services
-		if err := startLogStreaming(ctx, ctr, svc.name); err != nil {
-			logger.Log.Warnf("could not start log streaming for %s: %v", svc.name, err)
-		}
-
-		// only wait and export for services with ports (matches old behavior)
-		if len(svc.ports) == 0 {
-			logger.Log.Infof("service %s has no ports, skipping wait and export", svc.name)
-			continue
-		}
-
-		// export host env
-		host, err := ctr.Host(ctx)
-		if err != nil {
-			return fmt.Errorf("get host for %s error: %v", svc.name, err)
-		}
-		if err := exportComposeEnv(fmt.Sprintf("%s_host", svc.name), host, svc.name); err != nil {
-			return err
-		}
-		for _, port := range svc.ports {
-			portStr := fmt.Sprintf("%d/tcp", port)
-
-			// wait for port readiness
-			if err := waitForPort(ctx, ctr, portStr, waitTimeout); err != nil {
-				return fmt.Errorf("wait for port %d on %s error: %v", port, svc.name, err)
-			}
-
-			mappedPort, err := ctr.MappedPort(ctx, portStr)
-			if err != nil {
-				return fmt.Errorf("get mapped port %d for %s error: %v", port, svc.name, err)
-			}
-			if err := exportComposeEnv(
-				fmt.Sprintf("%s_%d", svc.name, port),
-				strconv.Itoa(int(mappedPort.Num())),
-				svc.name,
-			); err != nil {
-				return err
-			}
-		}
+	// wait for ports, expose env vars and logs for each service after its dependencies,
+	// they share the timeout with the steps and waits
+	timeout := e2eConfig.Setup.GetTimeout()
+	timeBefore := time.Now()
+	oneShots := composeOneShots(project, e2eConfig.Setup.Compose.OneShot)
+	err = project.ForEachService(project.ServiceNames(), func(name string, service *types.ServiceConfig) error {
+		return exposeComposeService(ctx, stack, service, oneShots[name], NewTimeout(timeBefore, timeout))
+	})
+	if err != nil {
+		return err
 	}
 
 	// run post-compose setup steps
-	if err := RunStepsAndWait(e2eConfig.Setup.Steps, e2eConfig.Setup.GetTimeout(), nil); err != nil {
+	if err := RunStepsAndWait(e2eConfig.Setup.Steps, NewTimeout(timeBefore, timeout), nil); err != nil {
 		logger.Log.Errorf("execute steps error: %v", err)
 		return err
 	}
 
-	return nil
+	return waitForEnvironment(e2eConfig.Setup.Waits, NewTimeout(timeBefore, timeout), nil)
 }
 
-// composeService holds a service name and its container ports.
-type composeService struct {
-	name  string
-	ports []int
+// loadComposeProject loads the compose files like docker compose does, the variables are interpolated from
+// the environment of the setup, and only the services of the enabled profiles are kept.
+func loadComposeProject(ctx context.Context, files, profiles []string, name string) (*types.Project, error) {
+	options, err := cli.NewProjectOptions(files,
+		cli.WithName(name),
+		cli.WithEnv(util.Env.Snapshot()),
+		cli.WithDotEnv,
+		cli.WithDefaultProfiles(profiles...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return options.LoadProject(ctx)
 }
 
-// parseComposeFile reads the docker-compose YAML and extracts service names and port mappings.
-func parseComposeFile(path string) ([]*composeService, error) {
-	data, err := os.ReadFile(path)
+// composeOneShots returns the services which run to completion, the configured ones, and the ones other services
+// depend on with the condition `service_completed_successfully`.
+func composeOneShots(project *types.Project, configured []string) map[string]bool {
+	oneShots := make(map[string]bool)
+	for _, name := range configured {
+		oneShots[name] = true
+	}
+	for _, service := range project.Services {
+		for name, dependency := range service.DependsOn {
+			if dependency.Condition == types.ServiceConditionCompletedSuccessfully {
+				oneShots[name] = true
+			}
+		}
+	}
+	return oneShots
+}
+
+// exposeComposeService follows the logs of the service, waits for it to be ready, and exports its host and ports.
+func exposeComposeService(ctx context.Context, stack *compose.DockerCompose, service *types.ServiceConfig, oneShot bool,
+	timeout time.Duration) error {
+	timeBefore := time.Now()
+	ctr, err := stack.ServiceContainer(ctx, service.Name)
 	if err != nil {
-		return nil, err
+		logger.Log.Warnf("could not get container for service %s: %v", service.Name, err)
+		return nil
 	}
 
-	var composeFile struct {
-		Services map[string]struct {
-			Ports []any `yaml:"ports"`
-		} `yaml:"services"`
+	// start log streaming for all services
+	if err := startLogStreaming(ctx, ctr, service.Name); err != nil {
+		logger.Log.Warnf("could not start log streaming for %s: %v", service.Name, err)
 	}
-	if err := yaml.Unmarshal(data, &composeFile); err != nil {
-		return nil, err
+
+	// only wait for the one-shot services, and the services with ports or healthcheck (matches old behavior),
+	// the others may exit after they're done, such as initializing the data
+	healthCheck := service.HealthCheck != nil && !service.HealthCheck.Disable
+	if !oneShot && !healthCheck && len(service.Ports) == 0 {
+		logger.Log.Infof("service %s has no ports or healthcheck, skipping wait and export", service.Name)
+		return nil
+	}
+
+	// wait for the one-shot service to exit, or the service to be running and healthy if it has healthcheck
+	if err := waitForService(ctx, ctr, service.Name, oneShot, timeout); err != nil {
+		return fmt.Errorf("%v, see the logs in %s", err, logFollower.LogFile(fmt.Sprintf("%s/std.log", service.Name)))
+	}
+	if oneShot || len(service.Ports) == 0 {
+		return nil
 	}
 
-	var services []*composeService
-	for name, svc := range composeFile.Services {
-		cs := &composeService{name: name}
-		for _, p := range svc.Ports {
-			port, err := parseContainerPort(p)
-			if err != nil {
-				return nil, fmt.Errorf("service %s: %v", name, err)
+	// export host env
+	host, err := ctr.Host(ctx)
+	if err != nil {
+		return fmt.Errorf("get host for %s error: %v", service.Name, err)
+	}
+	exportEnv(fmt.Sprintf("%s_host", service.Name), host)
+	for _, port := range service.Ports {
+		portStr, envKey := composePort(service.Name, port)
+
+		// wait for port readiness, the udp ports could not be checked by connecting
+		if port.Protocol != "udp" {
+			if err := waitForPort(ctx, ctr, portStr, NewTimeout(timeBefore, timeout)); err != nil {
+				return fmt.Errorf("wait for port %s on %s error: %v", portStr, service.Name, err)
 			}
-			cs.ports = append(cs.ports, port)
 		}
-		services = append(services, cs)
+
+		mappedPort, err := ctr.MappedPort(ctx, portStr)
+		if err != nil {
+			return fmt.Errorf("get mapped port %s for %s error: %v", portStr, service.Name, err)
+		}
+		exportEnv(envKey, strconv.Itoa(int(mappedPort.Num())))
 	}
-	return services, nil
+	return nil
 }
 
-// parseContainerPort extracts the container port from a port config.
-// Supports formats: 8080, "8080", "8080:80", "0.0.0.0:8080:80"
-func parseContainerPort(portConfig any) (int, error) {
-	switch conf := portConfig.(type) {
-	case int:
-		return conf, nil
-	case string:
-		parts := strings.Split(conf, ":")
-		// last part is always the container port (possibly with /protocol)
-		containerPart := parts[len(parts)-1]
-		containerPart = strings.Split(containerPart, "/")[0] // remove /tcp, /udp
-		return strconv.Atoi(containerPart)
-	}
-	return 0, fmt.Errorf("unknown port format: %v", portConfig)
+// composePort returns the container port in the form of `<port>/<protocol>`, and the key of the environment variable
+// exporting its mapped port, which is `<service>_<port>`, or `<service>_<port>_udp` for the udp port.
+func composePort(service string, port types.ServicePortConfig) (portStr, envKey string) {
+	protocol := port.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	portStr = fmt.Sprintf("%d/%s", port.Target, protocol)
+	envKey = fmt.Sprintf("%s_%d", service, port.Target)
+	if protocol != "tcp" {
+		envKey = fmt.Sprintf("%s_%s", envKey, protocol)
+	}
+	return portStr, envKey
 }
 
 // fileLogConsumer writes container logs to a file.
@@ -201,7 +230,8 @@ type fileLogConsumer struct {
 }
 
 func (f *fileLogConsumer) Accept(log testcontainers.Log) {
-	if _, err := f.writer.Write(log.Content); err != nil {
+	// the log file is closed along with the log follower, while the container may be still running
+	if _, err := f.writer.Write(log.Content); err != nil && !errors.Is(err, os.ErrClosed) {
 		logger.Log.Warnf("write %s log error: %v", f.service, err)
 	}
 }
@@ -220,6 +250,73 @@ func startLogStreaming(ctx context.Context, ctr *testcontainers.DockerContainer,
 	return ctr.StartLogProducer(ctx)
 }
 
+// waitForService waits until the one-shot service exits with code 0, or the service with healthcheck is healthy,
+// it fails as soon as the container exits unexpectedly or becomes unhealthy. The services without healthcheck are ready
+// once they are running.
+func waitForService(ctx context.Context, ctr *testcontainers.DockerContainer, name string, oneShot bool, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		ctrState, err := ctr.State(ctx)
+		if err != nil {
+			return fmt.Errorf("get state of %s error: %v", name, err)
+		}
+		ready, err := serviceReady(name, oneShot, ctrState)
+		if err != nil || ready {
+			return err
+		}
+		if oneShot {
+			logger.Log.Debugf("waiting for one-shot service %s to exit", name)
+		} else {
+			logger.Log.Debugf("waiting for service %s to be healthy", name)
+		}
+
+		select {
+		case <-time.After(serviceStateInterval):
+		case <-ctx.Done():
+			if oneShot {
+				return fmt.Errorf("wait for one-shot service %s to exit timeout after %v", name, timeout)
+			}
+			return fmt.Errorf("wait for service %s to be healthy timeout after %v%s", name, timeout, lastHealthProbe(ctrState))
+		}
+	}
+}
+
+// serviceReady checks the state of the service container, the error means it would never be ready.
+func serviceReady(name string, oneShot bool, ctrState *container.State) (bool, error) {
+	exited := ctrState.Status == container.StateExited || ctrState.Status == container.StateDead
+	if oneShot {
+		if exited && ctrState.ExitCode != 0 {
+			return false, fmt.Errorf("one-shot service %s exited with code %d", name, ctrState.ExitCode)
+		}
+		return exited, nil
+	}
+
+	if exited {
+		return false, fmt.Errorf("service %s exited with code %d%s", name, ctrState.ExitCode, lastHealthProbe(ctrState))
+	}
+	if ctrState.Health == nil {
+		return true, nil
+	}
+	switch ctrState.Health.Status {
+	case container.Healthy, container.NoHealthcheck:
+		return true, nil
+	case container.Unhealthy:
+		return false, fmt.Errorf("service %s is unhealthy%s", name, lastHealthProbe(ctrState))
+	}
+	return false, nil
+}
+
+// lastHealthProbe describes the result of the last health probe of the container, if any.
+func lastHealthProbe(ctrState *container.State) string {
+	if ctrState.Health == nil || len(ctrState.Health.Log) == 0 {
+		return ""
+	}
+	probe := ctrState.Health.Log[len(ctrState.Health.Log)-1]
+	return fmt.Sprintf(", the last health probe exited with code %d: %s", probe.ExitCode, strings.TrimSpace(probe.Output))
+}
+
 // waitForPort waits until the container port is ready by:
 // 1. External TCP dial — poll until connection succeeds from host
 // 2. Internal container check — verify port is listening inside the container
@@ -248,6 +345,9 @@ func waitForPort(ctx context.Context, ctr *testcontainers.DockerContainer, port
 		}
 		conn, err := net.DialTimeout("tcp", address, time.Second)
 		if err != nil {
+			if ctrState, err := ctr.State(ctx); err == nil && (ctrState.Status == container.StateExited || ctrState.Status == container.StateDead) {
+				return fmt.Errorf("container exited with code %d before port %s is ready", ctrState.ExitCode, port)
+			}
 			logger.Log.Debugf("port %s not ready yet: %v", address, err)
 			time.Sleep(waitInterval)
 			continue
@@ -296,11 +396,3 @@ func buildInternalCheckCommand(internalPort int) string {
 				`
 	return "true && " + fmt.Sprintf(command, internalPort, internalPort, internalPort)
 }
-
-func exportComposeEnv(key, value, service string) error {
-	if err := os.Setenv(key, value); err != nil {
-		return fmt.Errorf("could not set env for %s, %v", service, err)
-	}
-	logger.Log.Infof("export %s=%s", key, value)
-	return nil
-}
@@ -0,0 +1,206 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func TestLoadComposeProject(t *testing.T) {
+	env := util.Env
+	util.Env = util.NewEnvStore()
+	t.Cleanup(func() { util.Env = env })
+	util.Env.Set("OAP_GRPC_PORT", "11800")
+	dir := t.TempDir()
+	files := map[string]string{
+		"docker-compose.yml": `
+include:
+  - storage.yml
+services:
+  oap:
+    extends:
+      file: base.yml
+      service: java
+    image: apache/skywalking-oap-server
+    ports:
+      - ${OAP_GRPC_PORT}
+      - target: 12800
+        published: "0"
+      - 8125/udp
+  agent:
+    image: agent
+    ports:
+      - "9000-9001"
+  debug:
+    image: debug
+    profiles: [debug]
+    ports:
+      - 5005
+  ui:
+    image: apache/skywalking-ui
+    profiles: [ui]
+`,
+		"base.yml": `
+services:
+  java:
+    image: java
+    ports:
+      - 9090
+`,
+		"storage.yml": `
+services:
+  banyandb:
+    image: apache/skywalking-banyandb
+    ports:
+      - 17912
+`,
+		"override.yml": `
+services:
+  ui:
+    ports:
+      - 8080:8080
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	project, err := loadComposeProject(context.Background(), []string{
+		filepath.Join(dir, "docker-compose.yml"), filepath.Join(dir, "override.yml"),
+	}, []string{"ui"}, "e2e-test")
+	if err != nil {
+		t.Fatalf("loadComposeProject() error: %v", err)
+	}
+
+	exported := make(map[string][]string)
+	for name, service := range project.Services {
+		for _, port := range service.Ports {
+			portStr, envKey := composePort(name, port)
+			exported[name] = append(exported[name], portStr+" "+envKey)
+		}
+		sort.Strings(exported[name])
+	}
+	want := map[string][]string{
+		"oap":      {"11800/tcp oap_11800", "12800/tcp oap_12800", "8125/udp oap_8125_udp", "9090/tcp oap_9090"},
+		"agent":    {"9000/tcp agent_9000", "9001/tcp agent_9001"},
+		"banyandb": {"17912/tcp banyandb_17912"},
+		"ui":       {"8080/tcp ui_8080"},
+	}
+	if !reflect.DeepEqual(exported, want) {
+		t.Errorf("loadComposeProject() ports = %v, want %v", exported, want)
+	}
+}
+
+func TestComposeOneShots(t *testing.T) {
+	project := &types.Project{Services: types.Services{
+		"init-db":  {Name: "init-db"},
+		"migrate":  {Name: "migrate"},
+		"banyandb": {Name: "banyandb"},
+		"oap": {Name: "oap", DependsOn: types.DependsOnConfig{
+			"migrate":  {Condition: types.ServiceConditionCompletedSuccessfully},
+			"banyandb": {Condition: types.ServiceConditionHealthy},
+		}},
+	}}
+	want := map[string]bool{"init-db": true, "migrate": true}
+	if got := composeOneShots(project, []string{"init-db"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("composeOneShots() = %v, want %v", got, want)
+	}
+}
+
+func TestServiceReady(t *testing.T) {
+	unhealthyProbes := []*container.HealthcheckResult{
+		{ExitCode: 0, Output: "ok"},
+		{ExitCode: 1, Output: "curl: (7) Failed to connect to localhost port 12800\n"},
+	}
+	tests := []struct {
+		name      string
+		oneShot   bool
+		state     container.State
+		wantReady bool
+		wantErr   string
+	}{
+		{
+			name:      "Running without healthcheck",
+			state:     container.State{Status: container.StateRunning, Running: true},
+			wantReady: true,
+		},
+		{
+			name:  "Health starting",
+			state: container.State{Status: container.StateRunning, Running: true, Health: &container.Health{Status: container.Starting}},
+		},
+		{
+			name:      "Healthy",
+			state:     container.State{Status: container.StateRunning, Running: true, Health: &container.Health{Status: container.Healthy}},
+			wantReady: true,
+		},
+		{
+			name:    "Unhealthy",
+			state:   container.State{Status: container.StateRunning, Running: true, Health: &container.Health{Status: container.Unhealthy, Log: unhealthyProbes}},
+			wantErr: "service oap is unhealthy, the last health probe exited with code 1: curl: (7) Failed to connect to localhost port 12800",
+		},
+		{
+			name:    "Exited while starting",
+			state:   container.State{Status: container.StateExited, ExitCode: 137, Health: &container.Health{Status: container.Starting, Log: unhealthyProbes}},
+			wantErr: "service oap exited with code 137, the last health probe exited with code 1: curl: (7) Failed to connect to localhost port 12800",
+		},
+		{
+			name:    "One-shot running",
+			oneShot: true,
+			state:   container.State{Status: container.StateRunning, Running: true},
+		},
+		{
+			name:      "One-shot completed",
+			oneShot:   true,
+			state:     container.State{Status: container.StateExited},
+			wantReady: true,
+		},
+		{
+			name:    "One-shot failed",
+			oneShot: true,
+			state:   container.State{Status: container.StateExited, ExitCode: 1},
+			wantErr: "one-shot service oap exited with code 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, err := serviceReady("oap", tt.oneShot, &tt.state)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("serviceReady() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("serviceReady() error = %v, want %q", err, tt.wantErr)
+			}
+			if ready != tt.wantReady {
+				t.Fatalf("serviceReady() = %v, want %v", ready, tt.wantReady)
+			}
+		})
+	}
+}
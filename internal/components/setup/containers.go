@@ -0,0 +1,227 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/network"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// ContainersSetup starts the containers declared in `setup.containers` in the order of their dependencies,
+// all of them join a shared network and reach each other by their names.
+func ContainersSetup(e2eConfig *config.E2EConfig) error {
+	containers, err := config.SortContainers(e2eConfig.Setup.Containers)
+	if err != nil {
+		return err
+	}
+
+	// load environment variables from env file
+	if e2eConfig.Setup.InitSystemEnvironment != "" {
+		profilePath := util.ResolveAbs(e2eConfig.Setup.InitSystemEnvironment)
+		util.ExportEnvVars(profilePath)
+	}
+
+	// Disable Ryuk reaper when cleanup.on is "never" so containers survive process exit,
+	// it's read by testcontainers from the e2e process rather than the environment store.
+	if e2eConfig.Cleanup.On == constant.CleanUpNever {
+		if err := os.Setenv("TESTCONTAINERS_RYUK_DISABLED", "true"); err != nil {
+			return fmt.Errorf("failed to disable Ryuk reaper: %v", err)
+		}
+	}
+
+	timeout := e2eConfig.Setup.GetTimeout()
+	timeBefore := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	networks, err := createNetworks(ctx, containers)
+	if err != nil {
+		return err
+	}
+	for idx := range containers {
+		if err := startContainer(ctx, &containers[idx], networks, NewTimeout(timeBefore, timeout)); err != nil {
+			return err
+		}
+	}
+
+	if err := RunStepsAndWait(e2eConfig.Setup.Steps, NewTimeout(timeBefore, timeout), nil); err != nil {
+		logger.Log.Errorf("execute steps error: %v", err)
+		return err
+	}
+	return waitForEnvironment(e2eConfig.Setup.Waits, NewTimeout(timeBefore, timeout), nil)
+}
+
+// createNetworks creates the shared network, keyed by the empty name, and the networks declared by the containers,
+// the names of them are generated by docker so the parallel runs don't conflict.
+func createNetworks(ctx context.Context, containers []config.Container) (map[string]string, error) {
+	names := []string{""}
+	for idx := range containers {
+		names = append(names, containers[idx].Networks...)
+	}
+
+	networks := make(map[string]string)
+	for _, name := range names {
+		if _, ok := networks[name]; ok {
+			continue
+		}
+		nw, err := network.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("create network %s error: %v", name, err)
+		}
+		networks[name] = nw.Name
+		state.Update(func(s *state.State) { s.Networks = append(s.Networks, nw.Name) })
+		logger.Log.Infof("network %s created: %s", name, nw.Name)
+	}
+	return networks, nil
+}
+
+func startContainer(ctx context.Context, container *config.Container, networks map[string]string, timeout time.Duration) error {
+	env := make(map[string]string, len(container.Env))
+	for key, value := range container.Env {
+		env[key] = util.ExpandEnv(value)
+	}
+	exposedPorts, err := containerPorts(container)
+	if err != nil {
+		return err
+	}
+	networkNames := []string{networks[""]}
+	aliases := map[string][]string{networks[""]: {container.Name}}
+	for _, name := range container.Networks {
+		networkNames = append(networkNames, networks[name])
+		aliases[networks[name]] = []string{container.Name}
+	}
+
+	logWriter, err := logFollower.BuildLogWriter(fmt.Sprintf("%s/std.log", container.Name))
+	if err != nil {
+		return fmt.Errorf("create log file for %s error: %v", container.Name, err)
+	}
+
+	logger.Log.Infof("starting container %s [%s]", container.Name, container.Image)
+	ctr, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:           fmt.Sprintf("%s-%s", util.GetIdentity(), container.Name),
+			Image:          util.ExpandEnv(container.Image),
+			Env:            env,
+			ExposedPorts:   exposedPorts,
+			Cmd:            container.Command,
+			Networks:       networkNames,
+			NetworkAliases: aliases,
+			WaitingFor:     buildContainerWait(container, exposedPorts, timeout),
+			LogConsumerCfg: &testcontainers.LogConsumerConfig{
+				Consumers: []testcontainers.LogConsumer{&fileLogConsumer{writer: logWriter, service: container.Name}},
+			},
+		},
+	})
+	// the container is created even if it's not ready, it should be removed by the cleanup
+	if ctr != nil {
+		state.Update(func(s *state.State) {
+			if s.Containers == nil {
+				s.Containers = make(map[string]string)
+			}
+			s.Containers[container.Name] = ctr.GetContainerID()
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("create container %s error: %v", container.Name, err)
+	}
+	// the logs are produced with the context of the start until the log follower is closed,
+	// rather than the one of the setup, and the wait strategy is limited by its own deadline
+	if err := ctr.Start(logFollower.Ctx); err != nil {
+		return fmt.Errorf("start container %s error: %v, see the logs in %s", container.Name, err, logWriter.Name())
+	}
+
+	host, err := ctr.Host(ctx)
+	if err != nil {
+		return fmt.Errorf("get host for %s error: %v", container.Name, err)
+	}
+	exportEnv(fmt.Sprintf("%s_host", container.Name), host)
+	for _, port := range exposedPorts {
+		mappedPort, err := ctr.MappedPort(ctx, port)
+		if err != nil {
+			return fmt.Errorf("get mapped port %s for %s error: %v", port, container.Name, err)
+		}
+		// the same as the compose env, the udp port is exported as `<name>_<port>_udp`
+		number, protocol, _ := config.ParseContainerPort(port)
+		key := fmt.Sprintf("%s_%d", container.Name, number)
+		if protocol != "tcp" {
+			key = fmt.Sprintf("%s_%s", key, protocol)
+		}
+		exportEnv(key, strconv.Itoa(int(mappedPort.Num())))
+	}
+	return nil
+}
+
+// containerPorts converts the ports of the container to the form of `<port>/<protocol>`.
+func containerPorts(container *config.Container) ([]string, error) {
+	ports := make([]string, 0, len(container.Ports))
+	for _, port := range container.Ports {
+		number, protocol, err := config.ParseContainerPort(port)
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %v", container.Name, err)
+		}
+		ports = append(ports, fmt.Sprintf("%d/%s", number, protocol))
+	}
+	return ports, nil
+}
+
+// buildContainerWait builds the strategy to check the container is ready, which waits for all the tcp ports
+// to be listening, and for the http path, the log line or the command if it's set.
+func buildContainerWait(container *config.Container, exposedPorts []string, timeout time.Duration) wait.Strategy {
+	var strategies []wait.Strategy
+	waitPort := container.Wait.Port
+	for _, port := range exposedPorts {
+		// the udp ports could not be checked by connecting
+		number, protocol, _ := config.ParseContainerPort(port)
+		if protocol != "tcp" {
+			continue
+		}
+		strategies = append(strategies, wait.ForListeningPort(port))
+		if waitPort == 0 {
+			waitPort = number
+		}
+	}
+
+	switch {
+	case container.Wait.HTTP != "":
+		strategies = append(strategies, wait.ForHTTP(container.Wait.HTTP).WithPort(fmt.Sprintf("%d/tcp", waitPort)))
+	case container.Wait.Log != "":
+		strategies = append(strategies, wait.ForLog(container.Wait.Log).AsRegexp())
+	case container.Wait.Command != "":
+		strategies = append(strategies, wait.ForExec([]string{"/bin/sh", "-c", container.Wait.Command}))
+	case container.Wait.Port != 0:
+		strategies = append(strategies, wait.ForListeningPort(fmt.Sprintf("%d/tcp", container.Wait.Port)))
+	}
+	if len(strategies) == 0 {
+		return nil
+	}
+	return wait.ForAll(strategies...).WithDeadline(timeout)
+}
@@ -0,0 +1,189 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// helmRelease is the part of `helm status -o json` reported when the release fails.
+type helmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status      string `json:"status"`
+		Description string `json:"description"`
+	} `json:"info"`
+	Hooks []struct {
+		Name    string   `json:"name"`
+		Kind    string   `json:"kind"`
+		Events  []string `json:"events"`
+		LastRun struct {
+			Phase string `json:"phase"`
+		} `json:"last_run"`
+	} `json:"hooks"`
+}
+
+// String formats the status of the release and the hooks not succeeded.
+func (r *helmRelease) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "release %s in namespace %s, revision %d, status: %s", r.Name, r.Namespace, r.Version, r.Info.Status)
+	if r.Info.Description != "" {
+		fmt.Fprintf(&sb, ", description: %s", r.Info.Description)
+	}
+	for _, hook := range r.Hooks {
+		if hook.LastRun.Phase == "" || hook.LastRun.Phase == "Succeeded" {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n  hook %s (%s, %s): %s", hook.Name, hook.Kind, strings.Join(hook.Events, ","), hook.LastRun.Phase)
+	}
+	return sb.String()
+}
+
+// installHelmAndWait installs the release of the helm step, then waits for the conditions of the step.
+func installHelmAndWait(ctx context.Context, step config.Step, timeout time.Duration, cluster *util.K8sClusterInfo) error {
+	timeBefore := time.Now()
+	if err := installHelmRelease(ctx, step.Helm, cluster, timeout); err != nil {
+		return err
+	}
+	for idx := range step.Waits {
+		wait := step.Waits[idx]
+		logger.Log.Infof("waiting for %+v", wait)
+		if err := runWait(ctx, cluster, &wait, NewTimeout(timeBefore, timeout)); err != nil {
+			return fmt.Errorf("helm release %s waits error: %v", step.Helm.Release, err)
+		}
+		logger.Log.Infof("wait %+v condition met", wait)
+	}
+	return nil
+}
+
+// installHelmRelease installs or upgrades the release of the helm step, and waits for its resources to be ready,
+// the release is installed in the namespace of the cluster if the step doesn't specify one.
+func installHelmRelease(ctx context.Context, helm *config.HelmStep, cluster *util.K8sClusterInfo, timeout time.Duration) error {
+	namespace := cluster.ResolveNamespace(util.ExpandEnv(helm.Namespace))
+	args, err := buildHelmArgs(helm, namespace, timeout)
+	if err != nil {
+		return err
+	}
+	logger.Log.Infof("installing helm release %s [%s %s]", helm.Release, constant.HelmCommand, strings.Join(args, " "))
+
+	// the release may be created even if the installation fails, it should be uninstalled by the cleanup
+	state.Update(func(s *state.State) {
+		for _, installed := range s.HelmReleases {
+			if installed.Name == helm.Release && installed.Namespace == namespace {
+				return
+			}
+		}
+		s.HelmReleases = append(s.HelmReleases, state.HelmRelease{Name: helm.Release, Namespace: namespace, Order: s.NextOrder()})
+	})
+
+	// wait a little longer than helm, to get its own timeout error
+	ctx, cancel := context.WithTimeout(ctx, timeout+10*time.Second)
+	defer cancel()
+	stdout, stderr, err := util.RunHelm(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("install helm release %s error: %v, stderr: %s\n%s", helm.Release, err, stderr, helmReleaseStatus(helm.Release, namespace))
+	}
+	logger.Log.Infof("helm release %s installed: %s", helm.Release, stdout)
+	return nil
+}
+
+// buildHelmArgs builds the arguments of `helm upgrade --install`, so the step could be rerun against an existing cluster.
+func buildHelmArgs(helm *config.HelmStep, namespace string, timeout time.Duration) ([]string, error) {
+	chart, err := resolveHelmChart(helm)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"upgrade", "--install", helm.Release, chart}
+	if helm.Repo != "" {
+		args = append(args, "--repo", util.ExpandEnv(helm.Repo))
+	}
+	if helm.Version != "" {
+		args = append(args, "--version", util.ExpandEnv(helm.Version))
+	}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace, "--create-namespace")
+	}
+	for _, values := range helm.Values {
+		args = append(args, "--values", util.ResolveAbs(util.ExpandEnv(values)))
+	}
+
+	// sort the keys, so the latter ones are applied the same order every time
+	keys := make([]string, 0, len(helm.Set))
+	for key := range helm.Set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--set", fmt.Sprintf("%s=%s", key, util.ExpandEnv(helm.Set[key])))
+	}
+
+	if !helm.NoWait {
+		args = append(args, "--wait", "--timeout", timeout.String())
+	}
+	return args, nil
+}
+
+// resolveHelmChart resolves the local chart path relative to the config file, and keeps the chart name in the repo as it is.
+func resolveHelmChart(helm *config.HelmStep) (string, error) {
+	chart := util.ExpandEnv(helm.Chart)
+	if helm.Repo != "" || strings.Contains(chart, "://") {
+		return chart, nil
+	}
+	if abs := util.ResolveAbs(chart); util.PathExist(abs) {
+		return abs, nil
+	}
+	// report the missing local chart, rather than letting helm look for it in the repos
+	if config.IsHelmChartPath(chart) {
+		return "", fmt.Errorf("the local chart %s of helm release %s does not exist", util.ResolveAbs(chart), helm.Release)
+	}
+	return chart, nil
+}
+
+// helmReleaseStatus describes the status of the release and its hooks, for reporting the failure.
+func helmReleaseStatus(release, namespace string) string {
+	args := []string{"status", release, "--output", "json"}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	stdout, stderr, err := util.RunHelm(ctx, args...)
+	if err != nil {
+		return fmt.Sprintf("failed to get the status of release %s: %v, stderr: %s", release, err, stderr)
+	}
+
+	r := &helmRelease{}
+	if err := json.Unmarshal([]byte(stdout), r); err != nil {
+		return fmt.Sprintf("failed to parse the status of release %s: %v", release, err)
+	}
+	return r.String()
+}
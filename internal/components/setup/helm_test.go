@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func TestBuildHelmArgs(t *testing.T) {
+	util.Env.Set("OAP_TAG", "10.0.0")
+	tests := []struct {
+		name      string
+		helm      config.HelmStep
+		namespace string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name: "Chart in the repo",
+			helm: config.HelmStep{
+				Chart:   "skywalking",
+				Repo:    "https://apache.jfrog.io/artifactory/skywalking-helm",
+				Version: "4.5.0",
+				Release: "skywalking",
+				Set:     map[string]string{"oap.image.tag": "${OAP_TAG}", "oap.replicas": "1"},
+			},
+			namespace: "skywalking",
+			want: []string{
+				"upgrade", "--install", "skywalking", "skywalking",
+				"--repo", "https://apache.jfrog.io/artifactory/skywalking-helm",
+				"--version", "4.5.0",
+				"--namespace", "skywalking", "--create-namespace",
+				"--set", "oap.image.tag=10.0.0", "--set", "oap.replicas=1",
+				"--wait", "--timeout", "5m0s",
+			},
+		},
+		{
+			name: "Absolute values files and no wait",
+			helm: config.HelmStep{
+				Chart:   "oci://registry-1.docker.io/apache/skywalking-helm",
+				Release: "oap",
+				Values:  []string{"/tmp/values.yaml"},
+				NoWait:  true,
+			},
+			want: []string{
+				"upgrade", "--install", "oap", "oci://registry-1.docker.io/apache/skywalking-helm",
+				"--values", "/tmp/values.yaml",
+			},
+		},
+		{
+			name: "Missing local chart",
+			helm: config.HelmStep{
+				Chart:   "./charts/missing",
+				Release: "oap",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildHelmArgs(&tt.helm, tt.namespace, 5*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildHelmArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildHelmArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelmReleaseString(t *testing.T) {
+	status := `{
+  "name": "skywalking",
+  "namespace": "skywalking",
+  "version": 2,
+  "info": {"status": "failed", "description": "post-install hooks failed: job failed: BackoffLimitExceeded"},
+  "hooks": [
+    {"name": "skywalking-es-init", "kind": "Job", "events": ["post-install", "post-upgrade"], "last_run": {"phase": "Failed"}},
+    {"name": "skywalking-test", "kind": "Pod", "events": ["test"], "last_run": {"phase": ""}},
+    {"name": "skywalking-ui-init", "kind": "Job", "events": ["post-install"], "last_run": {"phase": "Succeeded"}}
+  ]
+}`
+	r := &helmRelease{}
+	if err := json.Unmarshal([]byte(status), r); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "release skywalking in namespace skywalking, revision 2, status: failed, " +
+		"description: post-install hooks failed: job failed: BackoffLimitExceeded\n" +
+		"  hook skywalking-es-init (Job, post-install,post-upgrade): Failed"
+	if got := r.String(); got != want {
+		t.Errorf("helmRelease.String() = %q, want %q", got, want)
+	}
+}
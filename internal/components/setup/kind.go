@@ -22,6 +22,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -34,12 +36,13 @@ import (
 	"sync/atomic"
 	"time"
 
-	apiv1 "k8s.io/api/admission/v1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/transport/spdy"
@@ -56,6 +59,7 @@ import (
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 )
 
@@ -170,8 +174,8 @@ func KindSetup(e2eConfig *config.E2EConfig) error {
 	}
 
 	steps := e2eConfig.Setup.Steps
-	// if no steps was provided, then no need to create the cluster.
-	if steps == nil {
+	// if no steps or waits was provided, then no need to create the cluster.
+	if steps == nil && len(e2eConfig.Setup.Waits) == 0 {
 		logger.Log.Info("no steps is provided")
 		return nil
 	}
@@ -189,18 +193,15 @@ func KindSetup(e2eConfig *config.E2EConfig) error {
 		}
 	} else {
 		// export the kubeconfig path for command line
-		err := os.Setenv("KUBECONFIG", kubeConfigPath)
-		if err != nil {
-			return fmt.Errorf("could not export kubeconfig file path, %v", err)
-		}
-		logger.Log.Infof("export KUBECONFIG=%s", kubeConfigPath)
+		exportEnv("KUBECONFIG", kubeConfigPath)
+		state.Update(func(s *state.State) { s.Kubeconfig = kubeConfigPath })
 	}
 
 	// import images
 	if len(e2eConfig.Setup.Kind.ImportImages) > 0 {
 		images := make([]string, 0, len(e2eConfig.Setup.Kind.ImportImages))
 		for _, image := range e2eConfig.Setup.Kind.ImportImages {
-			images = append(images, os.ExpandEnv(image))
+			images = append(images, util.ExpandEnv(image))
 		}
 		// pull images if this image not exist
 		if err := pullImages(context.Background(), images); err != nil {
@@ -227,6 +228,15 @@ func KindSetup(e2eConfig *config.E2EConfig) error {
 		return err
 	}
 
+	if e2eConfig.Setup.Kind.IsolateNamespace {
+		namespace, err := createIsolatedNamespace(cluster.Client)
+		if err != nil {
+			logger.Log.Errorf("create isolated namespace failed: %v", err)
+			return err
+		}
+		cluster = cluster.CopyClusterToNamespace(namespace)
+	}
+
 	listener := NewKindContainerListener(context.Background(), cluster)
 	defer listener.Stop()
 	err = listener.Listen(func(pod *v1.Pod) {
@@ -239,6 +249,7 @@ func KindSetup(e2eConfig *config.E2EConfig) error {
 	}
 
 	// run steps
+	timeBefore := time.Now()
 	err = RunStepsAndWait(e2eConfig.Setup.Steps, e2eConfig.Setup.GetTimeout(), cluster)
 	if err != nil {
 		logger.Log.Errorf("execute steps error: %v", err)
@@ -257,7 +268,36 @@ func KindSetup(e2eConfig *config.E2EConfig) error {
 		logger.Log.Errorf("export ports error: %v", err)
 		return err
 	}
-	return nil
+
+	// wait for the services, such as through the exposed ports
+	return waitForEnvironment(e2eConfig.Setup.Waits, NewTimeout(timeBefore, e2eConfig.Setup.GetTimeout()), cluster)
+}
+
+// createIsolatedNamespace creates a namespace unique to this run, and the objects without namespace or in the
+// default namespace are created in it, so the runs sharing a cluster don't collide with each other.
+func createIsolatedNamespace(client kubernetes.Interface) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s", constant.IsolatedNamespacePrefix, hex.EncodeToString(suffix))
+
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   name,
+		Labels: map[string]string{"app.kubernetes.io/managed-by": constant.FieldManager},
+	}}
+	if _, err := client.CoreV1().Namespaces().Create(context.Background(), namespace,
+		metav1.CreateOptions{FieldManager: constant.FieldManager}); err != nil {
+		return "", fmt.Errorf("create namespace %s error: %v", name, err)
+	}
+	// it's created before all the objects of the steps, so it's deleted after them by the cleanup
+	state.Update(func(s *state.State) {
+		s.Objects = append(s.Objects, state.Object{APIVersion: "v1", Kind: "Namespace", Name: name, Order: s.NextOrder()})
+	})
+	logger.Log.Infof("namespace %s is created for this run", name)
+
+	exportEnv(constant.IsolatedNamespaceEnv, name)
+	return name, nil
 }
 
 func checkKubeConfig(kindConfigPath string) error {
@@ -288,15 +328,25 @@ func KindCleanNotify() {
 
 func createKindCluster(kindConfigPath string, e2eConfig *config.E2EConfig) error {
 	// the config file name of the k8s cluster that kind create
-	kubeConfigPath = constant.K8sClusterConfigFilePath
+	kubeConfigPath = util.GetK8sClusterConfigFilePath()
+	clusterName, err := util.GetKindClusterName(kindConfigPath)
+	if err != nil {
+		return err
+	}
 	args := []string{
 		"create", "cluster",
 		"--config", kindConfigPath,
 		"--kubeconfig", kubeConfigPath,
+		"--name", clusterName,
 	}
 	if !e2eConfig.Setup.Kind.NoWait {
 		args = append(args, "--wait", e2eConfig.Setup.GetTimeout().String())
 	}
+	// recorded before creating, so the cluster could be cleaned up even if it's not ready
+	state.Update(func(s *state.State) {
+		s.KindCluster = clusterName
+		s.Kubeconfig = kubeConfigPath
+	})
 
 	logger.Log.Info("creating kind cluster...")
 	logger.Log.Debugf("cluster create commands: %s %s", constant.KindCommand, strings.Join(args, " "))
@@ -306,11 +356,7 @@ func createKindCluster(kindConfigPath string, e2eConfig *config.E2EConfig) error
 	logger.Log.Info("create kind cluster succeeded")
 
 	// export kubeconfig path for command line
-	err := os.Setenv("KUBECONFIG", kubeConfigPath)
-	if err != nil {
-		return fmt.Errorf("could not export kubeconfig file path, %v", err)
-	}
-	logger.Log.Infof("export KUBECONFIG=%s", kubeConfigPath)
+	exportEnv("KUBECONFIG", kubeConfigPath)
 	return nil
 }
 
@@ -319,7 +365,8 @@ func getWaitOptions(cluster *util.K8sClusterInfo, wait *config.Wait) (options *c
 		return nil, fmt.Errorf("when passing resource.group/resource.name in Resource, the labelSelector can not be set at the same time")
 	}
 
-	restClientGetter := cluster.CopyClusterToNamespace(wait.Namespace)
+	namespace := cluster.ResolveNamespace(wait.Namespace)
+	restClientGetter := cluster.CopyClusterToNamespace(namespace)
 	silenceOutput, _ := os.Open(os.DevNull)
 	ioStreams := genericclioptions.IOStreams{In: os.Stdin, Out: silenceOutput, ErrOut: os.Stderr}
 	waitFlags := ctlwait.NewWaitFlags(restClientGetter, ioStreams)
@@ -350,30 +397,47 @@ func getWaitOptions(cluster *util.K8sClusterInfo, wait *config.Wait) (options *c
 }
 
 func createByManifest(c *util.K8sClusterInfo, manifest config.Manifest) error {
-	files, err := util.GetManifests(manifest.Path)
+	sources, err := loadManifests(manifest)
 	if err != nil {
 		logger.Log.Error("get manifests failed")
 		return err
 	}
 
-	for _, f := range files {
-		logger.Log.Infof("creating manifest %s", f)
-		err = util.OperateManifest(c.Client, c.Interface, f, apiv1.Create)
+	for _, source := range sources {
+		logger.Log.Infof("creating manifest %s (%s)", source.name, manifest.GetMode())
+		created, err := util.OperateManifestContent(c.Client, c.Interface, source.content, manifest.GetMode(), c.Namespace())
+		// record the objects created before the failure too, so they could be deleted by the cleanup
+		state.Update(func(s *state.State) {
+			for _, obj := range created {
+				s.Objects = append(s.Objects, state.Object{
+					APIVersion: obj.GetAPIVersion(),
+					Kind:       obj.GetKind(),
+					Namespace:  obj.GetNamespace(),
+					Name:       obj.GetName(),
+					Order:      s.NextOrder(),
+				})
+			}
+		})
 		if err != nil {
-			logger.Log.Errorf("create manifest %s failed", f)
+			logger.Log.Errorf("create manifest %s failed", source.name)
 			return err
 		}
+		state.Update(func(s *state.State) { s.Manifests = append(s.Manifests, source.name) })
 	}
 	return nil
 }
 
-func concurrentlyWait(wait *config.Wait, options *ctlwait.WaitOptions, waitSet *util.WaitSet) {
+func concurrentlyWait(ctx context.Context, cluster *util.K8sClusterInfo, wait *config.Wait, waitSet *util.WaitSet) {
 	defer waitSet.WaitGroup.Done()
 
-	err := options.RunWait()
+	err := runWait(ctx, cluster, wait, waitSet.Timeout)
 	if err != nil {
 		err = fmt.Errorf("wait strategy :%+v, err: %s", wait, err)
-		waitSet.ErrChan <- err
+		// the first error is enough to fail the step
+		select {
+		case waitSet.ErrChan <- err:
+		default:
+		}
 		return
 	}
 	logger.Log.Infof("wait %+v condition met", wait)
@@ -444,11 +508,12 @@ func buildKindPort(port string, ro runtime.Object, pod *v1.Pod) (*kindPort, erro
 
 func exposePerKindService(port config.KindExposePort, timeout time.Duration, cluster *util.K8sClusterInfo,
 	client *rest.RESTClient, roundTripper http.RoundTripper, upgrader spdy.Upgrader, forward *kindPortForwardContext) error {
+	namespace := cluster.ResolveNamespace(port.Namespace)
 	// find resource
 	builder := resource.NewBuilder(cluster).
 		WithScheme(scheme.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
 		ContinueOnError().
-		NamespaceParam(port.Namespace).DefaultNamespace()
+		NamespaceParam(namespace).DefaultNamespace()
 	builder.ResourceNames("pods", port.Resource)
 	obj, err := builder.Do().Object()
 	if err != nil {
@@ -510,19 +575,13 @@ func exposePerKindService(port config.KindExposePort, timeout time.Duration, clu
 		resourceName := port.Resource
 		resourceName = strings.ReplaceAll(resourceName, "/", "_")
 		resourceName = strings.ReplaceAll(resourceName, "-", "_")
-		if err1 := exportKindEnv(fmt.Sprintf("%s_host", resourceName),
-			"localhost", port.Resource); err1 != nil {
-			return err1
-		}
+		exportEnv(fmt.Sprintf("%s_host", resourceName), "localhost")
 
 		// format: <resource>_<need_export_port>
 		for _, p := range exportedPorts {
 			for _, kp := range convertedPorts {
 				if int(p.Remote) == kp.realPort {
-					if err1 := exportKindEnv(fmt.Sprintf("%s_%s", resourceName, kp.inputPort),
-						fmt.Sprintf("%d", p.Local), port.Resource); err1 != nil {
-						return err1
-					}
+					exportEnv(fmt.Sprintf("%s_%s", resourceName, kp.inputPort), fmt.Sprintf("%d", p.Local))
 				}
 			}
 		}
@@ -628,6 +687,38 @@ func exposePerContainerLog(clientGetter *util.K8sClusterInfo, pod *v1.Pod, timeo
 	return nil
 }
 
+// podLogFiles returns the log files of the pods of the resource in the log wait, they are built once the pods are running.
+func podLogFiles(cluster *util.K8sClusterInfo, wait *config.LogWait) ([]string, error) {
+	namespace := cluster.ResolveNamespace(wait.Namespace)
+	builder := resource.NewBuilder(cluster).
+		WithScheme(scheme.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		ContinueOnError().
+		NamespaceParam(namespace).DefaultNamespace()
+	builder.ResourceNames("pods", util.ExpandEnv(wait.Resource))
+	obj, err := builder.Do().Object()
+	if err != nil {
+		return nil, err
+	}
+
+	if pod, ok := obj.(*v1.Pod); ok {
+		return []string{logFollower.LogFile(filepath.Join(pod.Namespace, fmt.Sprintf("%s.log", pod.Name)))}, nil
+	}
+	podNamespace, selector, err := polymorphichelpers.SelectorsForObject(obj)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the pods of %s: %v", wait.Resource, err)
+	}
+	pods, err := cluster.Client.CoreV1().Pods(podNamespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(pods.Items))
+	for idx := range pods.Items {
+		pod := &pods.Items[idx]
+		files = append(files, logFollower.LogFile(filepath.Join(pod.Namespace, fmt.Sprintf("%s.log", pod.Name))))
+	}
+	return files, nil
+}
+
 func exposeLogs(clientGetter *util.K8sClusterInfo, listener *KindContainerListener, timeout time.Duration) error {
 	pods, err := listener.GetAllPods()
 	if err != nil {
@@ -640,12 +731,3 @@ func exposeLogs(clientGetter *util.K8sClusterInfo, listener *KindContainerListen
 	}
 	return nil
 }
-
-func exportKindEnv(key, value, res string) error {
-	err := os.Setenv(key, value)
-	if err != nil {
-		return fmt.Errorf("could not set env for %s, %v", res, err)
-	}
-	logger.Log.Infof("export %s=%s", key, value)
-	return nil
-}
@@ -0,0 +1,51 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func TestCreateIsolatedNamespace(t *testing.T) {
+	env := util.Env
+	util.Env = util.NewEnvStore()
+	t.Cleanup(func() { util.Env = env })
+
+	client := fake.NewClientset()
+	name, err := createIsolatedNamespace(client)
+	if err != nil {
+		t.Fatalf("createIsolatedNamespace() error = %v", err)
+	}
+	if !strings.HasPrefix(name, constant.IsolatedNamespacePrefix+"-") {
+		t.Errorf("createIsolatedNamespace() = %s, want the prefix %s-", name, constant.IsolatedNamespacePrefix)
+	}
+	if _, err := client.CoreV1().Namespaces().Get(context.Background(), name, metav1.GetOptions{}); err != nil {
+		t.Errorf("namespace %s is not created: %v", name, err)
+	}
+	if got := util.Env.Get(constant.IsolatedNamespaceEnv); got != name {
+		t.Errorf("%s = %q, want %q", constant.IsolatedNamespaceEnv, got, name)
+	}
+}
@@ -0,0 +1,119 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/state"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+var (
+	backgroundNameRegex = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+	// backgroundProcesses is the count of the background processes started by this process, to name the unnamed ones.
+	backgroundProcesses int32
+)
+
+// LocalSetup runs the steps on the host, without docker or kubernetes.
+func LocalSetup(e2eConfig *config.E2EConfig) error {
+	// load environment variables from env file
+	if e2eConfig.Setup.InitSystemEnvironment != "" {
+		profilePath := util.ResolveAbs(e2eConfig.Setup.InitSystemEnvironment)
+		util.ExportEnvVars(profilePath)
+	}
+
+	timeBefore := time.Now()
+	if err := RunStepsAndWait(e2eConfig.Setup.Steps, e2eConfig.Setup.GetTimeout(), nil); err != nil {
+		logger.Log.Errorf("execute steps error: %v", err)
+		return err
+	}
+	return waitForEnvironment(e2eConfig.Setup.Waits, NewTimeout(timeBefore, e2eConfig.Setup.GetTimeout()), nil)
+}
+
+// startBackgroundAndWait starts the command of the step in a new process group, which keeps running until the cleanup,
+// and waits for it to be ready. The stdout and stderr of it are written to `<log-dir>/<step name>/std.log` directly,
+// rather than through a pipe, so it keeps running after the `e2e setup` process exits.
+func startBackgroundAndWait(ctx context.Context, step config.Step, timeout time.Duration) error {
+	count := atomic.AddInt32(&backgroundProcesses, 1)
+	name := backgroundNameRegex.ReplaceAllString(step.Name, "-")
+	if name == "" || name == "-" {
+		name = fmt.Sprintf("background-%d", count)
+	}
+
+	logWriter, err := logFollower.BuildLogWriter(fmt.Sprintf("%s/std.log", name))
+	if err != nil {
+		return fmt.Errorf("create log file for %s error: %v", name, err)
+	}
+	defer logWriter.Close()
+
+	cmd := exec.Command("bash", "-ec", step.Command)
+	cmd.Env = util.Env.Snapshot()
+	cmd.Stdout, cmd.Stderr = logWriter, logWriter
+	logger.Log.Infof("starting background process %s [%s]", name, step.Command)
+	if err := util.StartProcessGroup(cmd); err != nil {
+		return fmt.Errorf("start background process %s error: %v", name, err)
+	}
+	started, err := util.ProcessStartTime(cmd.Process.Pid)
+	if err != nil {
+		logger.Log.Warnf("get start time of background process %s error: %v", name, err)
+	}
+	process := state.Process{PID: cmd.Process.Pid, StartTime: started}
+	state.Update(func(s *state.State) { s.Processes = append(s.Processes, process) })
+
+	exited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		logger.Log.Infof("background process %s exited: %v", name, err)
+		exited <- err
+	}()
+
+	if len(step.Waits) == 0 {
+		return nil
+	}
+	waited := make(chan error, 1)
+	go func() {
+		for idx := range step.Waits {
+			wait := step.Waits[idx]
+			logger.Log.Infof("waiting for %+v", wait)
+			if err := runWait(ctx, nil, &wait, timeout); err != nil {
+				waited <- fmt.Errorf("background process %s waits error: %v", name, err)
+				return
+			}
+			logger.Log.Infof("wait %+v condition met", wait)
+		}
+		waited <- nil
+	}()
+
+	select {
+	case err := <-waited:
+		return err
+	case err := <-exited:
+		return fmt.Errorf("background process %s exited before it's ready: %v, see the logs in %s", name, err, logWriter.Name())
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,113 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// manifestSource is the content of a manifest file, or the output of a kustomization.
+type manifestSource struct {
+	name    string
+	content []byte
+}
+
+// loadManifests reads the manifest files or builds the kustomization of the step, and renders them by the step.
+func loadManifests(manifest config.Manifest) ([]manifestSource, error) {
+	var sources []manifestSource
+	if manifest.Kustomize != "" {
+		dir := util.ResolveAbs(util.ExpandEnv(manifest.Kustomize))
+		content, err := util.RenderKustomization(dir)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, manifestSource{name: dir, content: content})
+	} else {
+		files, err := util.GetManifests(manifest.Path)
+		if err != nil {
+			return nil, fmt.Errorf("get manifests failed: %v", err)
+		}
+		for _, f := range files {
+			content, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, manifestSource{name: f, content: content})
+		}
+	}
+
+	for idx := range sources {
+		rendered, err := renderManifest(sources[idx].name, sources[idx].content, manifest.Render)
+		if err != nil {
+			return nil, err
+		}
+		sources[idx].content = rendered
+	}
+	return sources, nil
+}
+
+// renderManifest expands the environment variables, or executes the Go template with config.TemplateData, in the manifest.
+func renderManifest(name string, content []byte, render string) ([]byte, error) {
+	switch render {
+	case "":
+		return content, nil
+	case constant.RenderEnv:
+		return []byte(util.ExpandEnv(string(content))), nil
+	case constant.RenderTemplate:
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parse manifest template %s error: %v", name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, config.NewTemplateData()); err != nil {
+			return nil, fmt.Errorf("render manifest template %s error: %v", name, err)
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unsupported render %s of manifest %s", render, name)
+}
+
+// RenderManifests writes the final manifests of the steps, which would be applied by the setup, without applying them.
+// The variables exported by the environment, such as the ports of the services, are not available here.
+func RenderManifests(steps []config.Step, w io.Writer) error {
+	for idx := range steps {
+		step := &steps[idx]
+		if step.Path == "" && step.Kustomize == "" {
+			continue
+		}
+		sources, err := loadManifests(config.Manifest{Path: step.Path, Kustomize: step.Kustomize, Render: step.Render})
+		if err != nil {
+			return fmt.Errorf("step [%s]: %v", step.Name, err)
+		}
+		for _, source := range sources {
+			if _, err := fmt.Fprintf(w, "---\n# Step: %s\n# Source: %s\n%s\n", step.Name, source.name, bytes.TrimSpace(source.content)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func TestRenderManifest(t *testing.T) {
+	util.Env.Set("OAP_TAG", "10.0.0")
+	config.GlobalConfig.Matrix = config.MatrixCombination{"storage": "banyandb"}
+	defer func() { config.GlobalConfig.Matrix = nil }()
+
+	tests := []struct {
+		name    string
+		content string
+		render  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Not rendered",
+			content: "image: apache/skywalking-oap-server:${OAP_TAG}",
+			want:    "image: apache/skywalking-oap-server:${OAP_TAG}",
+		},
+		{
+			name:    "Environment variables",
+			content: "image: apache/skywalking-oap-server:${OAP_TAG}",
+			render:  "env",
+			want:    "image: apache/skywalking-oap-server:10.0.0",
+		},
+		{
+			name:    "Go template",
+			content: "image: apache/skywalking-oap-server:{{ .Env.OAP_TAG }}\n{{- if eq .Matrix.storage \"banyandb\" }}\nstorage: banyandb{{ end }}",
+			render:  "template",
+			want:    "image: apache/skywalking-oap-server:10.0.0\nstorage: banyandb",
+		},
+		{
+			name:    "Missing variable in Go template",
+			content: "image: apache/skywalking-oap-server:{{ .Env.NOT_EXIST_TAG }}",
+			render:  "template",
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported render",
+			content: "image: apache/skywalking-oap-server",
+			render:  "envsubst",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderManifest("oap.yaml", []byte(tt.content), tt.render)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("renderManifest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderManifests(t *testing.T) {
+	util.Env.Set("OAP_TAG", "10.0.0")
+	manifest := filepath.Join(t.TempDir(), "oap.yaml")
+	if err := os.WriteFile(manifest, []byte("image: apache/skywalking-oap-server:${OAP_TAG}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	steps := []config.Step{
+		{Name: "install oap", Path: manifest, Render: "env"},
+		{Name: "wait", Command: "sleep 10"},
+	}
+	if err := RenderManifests(steps, &out); err != nil {
+		t.Fatalf("RenderManifests() error = %v", err)
+	}
+	want := "---\n# Step: install oap\n# Source: " + manifest + "\nimage: apache/skywalking-oap-server:10.0.0\n"
+	if out.String() != want {
+		t.Errorf("RenderManifests() = %q, want %q", out.String(), want)
+	}
+
+	steps = []config.Step{{Name: "missing", Path: filepath.Join(t.TempDir(), "missing.yaml")}}
+	if err := RenderManifests(steps, &out); err == nil || !strings.Contains(err.Error(), "step [missing]") {
+		t.Errorf("RenderManifests() error = %v, want the error of the step", err)
+	}
+}
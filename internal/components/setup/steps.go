@@ -0,0 +1,256 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pterm/pterm"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// The statuses of the setup steps in the summary.
+const (
+	stepSucceeded  = "succeeded"
+	stepSkipped    = "skipped"
+	stepFailed     = "failed"
+	stepIgnored    = "failed (continue-on-error)"
+	stepCancelled  = "cancelled"
+	stepNotStarted = "not started"
+)
+
+// stepResult is the outcome of a setup step.
+type stepResult struct {
+	name     string
+	status   string
+	skipped  bool
+	attempts int
+	duration time.Duration
+}
+
+// satisfied returns whether the steps needing it could start.
+func (r *stepResult) satisfied() bool {
+	return r.status == stepSucceeded || r.status == stepSkipped || r.status == stepIgnored
+}
+
+// RunStepsAndWait runs the steps and waits for their conditions within the timeout shared by them.
+// Every step starts after the steps it needs succeed, so the independent ones run concurrently,
+// and the running steps are cancelled once any of them fails.
+func RunStepsAndWait(steps []config.Step, waitTimeout time.Duration, k8sCluster *util.K8sClusterInfo) error {
+	logger.Log.Debugf("wait timeout is %v", waitTimeout.String())
+	if len(steps) == 0 {
+		return nil
+	}
+	dependencies, err := config.StepDependencies(steps)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(waitTimeout)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make([]*stepResult, len(steps))
+	finished := make([]chan struct{}, len(steps))
+	for idx := range steps {
+		results[idx] = &stepResult{name: steps[idx].DisplayName(idx), status: stepNotStarted}
+		finished[idx] = make(chan struct{})
+	}
+
+	var failure error
+	var failureLock sync.Mutex
+	var wg sync.WaitGroup
+	for idx := range steps {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			defer close(finished[idx])
+			for _, dependency := range dependencies[idx] {
+				select {
+				case <-finished[dependency]:
+				case <-ctx.Done():
+					return
+				}
+				if !results[dependency].satisfied() {
+					return
+				}
+			}
+
+			result := results[idx]
+			start := time.Now()
+			err := runStepAttempts(ctx, &steps[idx], result, deadline, k8sCluster)
+			result.duration = time.Since(start)
+
+			failureLock.Lock()
+			defer failureLock.Unlock()
+			switch {
+			case err == nil && result.skipped:
+				result.status = stepSkipped
+			case err == nil:
+				result.status = stepSucceeded
+			case failure != nil:
+				// it fails because of the cancellation, or along with the first failure
+				result.status = stepCancelled
+			case steps[idx].ContinueOnError:
+				result.status = stepIgnored
+				logger.Log.Warnf("setup step [%s] failed, continue on error: %v", result.name, err)
+			default:
+				result.status = stepFailed
+				failure = err
+				cancel()
+			}
+		}(idx)
+	}
+	wg.Wait()
+
+	logStepResults(results)
+	return failure
+}
+
+// runStepAttempts runs the step if its condition is met, and retries it until it succeeds or the retries are used up.
+func runStepAttempts(ctx context.Context, step *config.Step, result *stepResult, deadline time.Time,
+	k8sCluster *util.K8sClusterInfo) error {
+	if step.If != "" {
+		run, err := config.EvaluateCondition(step.If, config.NewTemplateData())
+		if err != nil {
+			return fmt.Errorf("evaluate the condition of step [%s] error: %v", result.name, err)
+		}
+		if !run {
+			logger.Log.Infof("skip setup step [%s], the condition %q is not met", result.name, step.If)
+			result.skipped = true
+			return nil
+		}
+	}
+	timeout, err := step.GetTimeout()
+	if err != nil {
+		return fmt.Errorf("the timeout of step [%s] error: %v", result.name, err)
+	}
+	intervals, err := step.Retry.Intervals()
+	if err != nil {
+		return fmt.Errorf("the retry of step [%s] error: %v", result.name, err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("setup timeout before step [%s] starts", result.name)
+		}
+		attemptTimeout := timeout
+		if attemptTimeout <= 0 || attemptTimeout > remaining {
+			attemptTimeout = remaining
+		}
+
+		result.attempts = attempt
+		if len(intervals) == 0 {
+			logger.Log.Infof("processing setup step [%s]", result.name)
+		} else {
+			logger.Log.Infof("processing setup step [%s], attempt %d/%d", result.name, attempt, len(intervals)+1)
+		}
+		err = runStepAttempt(ctx, *step, attemptTimeout, k8sCluster)
+		if err == nil || attempt > len(intervals) || ctx.Err() != nil {
+			return err
+		}
+
+		interval := intervals[attempt-1]
+		if time.Until(deadline) <= interval {
+			logger.Log.Warnf("setup step [%s] attempt %d failed, no time left to retry", result.name, attempt)
+			return err
+		}
+		logger.Log.Warnf("setup step [%s] attempt %d failed: %v, retry in %v", result.name, attempt, err, interval)
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// runStepAttempt runs the step once, and stops it if it doesn't finish within the timeout.
+func runStepAttempt(ctx context.Context, step config.Step, timeout time.Duration, k8sCluster *util.K8sClusterInfo) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	err := runStep(attemptCtx, step, timeout, k8sCluster)
+	// the waits of the step may find the timeout before the context
+	if err != nil && ctx.Err() == nil && time.Since(start) >= timeout {
+		return fmt.Errorf("step timeout after %v: %v", timeout, err)
+	}
+	return err
+}
+
+// runStep runs the step according to its kind.
+func runStep(ctx context.Context, step config.Step, timeout time.Duration, k8sCluster *util.K8sClusterInfo) error {
+	switch {
+	case step.Background:
+		return startBackgroundAndWait(ctx, step, timeout)
+	case step.Helm != nil:
+		if k8sCluster == nil {
+			return fmt.Errorf("not support helm")
+		}
+		return installHelmAndWait(ctx, step, timeout, k8sCluster)
+	case (step.Path != "" || step.Kustomize != "") && step.Command == "":
+		if k8sCluster == nil {
+			return fmt.Errorf("not support path")
+		}
+		manifest := config.Manifest{
+			Path:      step.Path,
+			Kustomize: step.Kustomize,
+			Mode:      step.Mode,
+			Render:    step.Render,
+			Waits:     step.Waits,
+		}
+		return createManifestAndWait(ctx, k8sCluster, manifest, timeout)
+	case step.Command != "" && step.Path == "":
+		command := config.Run{
+			Command: step.Command,
+			Waits:   step.Waits,
+		}
+		return RunCommandsAndWait(ctx, command, timeout, k8sCluster)
+	default:
+		return fmt.Errorf("step parameter error, one Path, Kustomize, Command or Helm should be specified, but got %+v", step)
+	}
+}
+
+// logStepResults logs the status, attempts and duration of every step in a table.
+func logStepResults(results []*stepResult) {
+	data := pterm.TableData{{"STEP", "STATUS", "ATTEMPTS", "DURATION"}}
+	for _, result := range results {
+		attempts, duration := "-", "-"
+		if result.attempts > 0 {
+			attempts = strconv.Itoa(result.attempts)
+		}
+		if result.status != stepNotStarted {
+			duration = result.duration.Round(time.Millisecond).String()
+		}
+		data = append(data, []string{result.name, result.status, attempts, duration})
+	}
+	table, err := pterm.DefaultTable.WithHasHeader().WithData(data).Srender()
+	if err != nil {
+		logger.Log.Warnf("failed to render the setup steps: %v", err)
+		return
+	}
+	logger.Log.Infof("setup steps:\n%s", pterm.RemoveColorFromString(table))
+}
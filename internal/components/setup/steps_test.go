@@ -0,0 +1,133 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+func TestRunStepsAndWait(t *testing.T) {
+	dir := t.TempDir()
+	marker := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	touch := func(name string) string {
+		return fmt.Sprintf("touch %s", marker(name))
+	}
+
+	tests := []struct {
+		name       string
+		steps      []config.Step
+		wantErr    string
+		wantExist  []string
+		wantAbsent []string
+	}{
+		{
+			name: "Run the steps after the ones they need",
+			steps: []config.Step{
+				{ID: "oap", Needs: []string{"es", "kafka"}, Command: fmt.Sprintf("test -f %s && test -f %s && %s",
+					marker("es"), marker("kafka"), touch("oap"))},
+				{ID: "es", Command: "sleep 0.2 && " + touch("es")},
+				{ID: "kafka", Command: touch("kafka")},
+			},
+			wantExist: []string{"es", "kafka", "oap"},
+		},
+		{
+			name: "Cancel the running steps and skip the following ones on failure",
+			steps: []config.Step{
+				{ID: "broken", Command: "exit 1"},
+				{ID: "slow", Command: "sleep 10 && " + touch("slow")},
+				{ID: "after", Needs: []string{"broken"}, Command: touch("after")},
+			},
+			wantErr:    "commands: [exit 1] runs error",
+			wantAbsent: []string{"slow", "after"},
+		},
+		{
+			name: "Retry the failed step",
+			steps: []config.Step{
+				{
+					Name:    "flaky",
+					Command: fmt.Sprintf("test -f %s || (touch %s && exit 1)", marker("tried"), marker("tried")),
+					Retry:   &config.StepRetry{Count: 2, Interval: "10ms", Backoff: 2},
+				},
+				{Name: "retried", Command: touch("retried")},
+			},
+			wantExist: []string{"tried", "retried"},
+		},
+		{
+			name: "Stop the step exceeding its timeout",
+			steps: []config.Step{
+				{Name: "slow", Command: "sleep 10 && " + touch("slow"), Timeout: "200ms"},
+			},
+			wantErr:    "step timeout after 200ms",
+			wantAbsent: []string{"slow"},
+		},
+		{
+			name: "Skip the step whose condition is not met and continue on error",
+			steps: []config.Step{
+				{ID: "skipped", If: `eq .Env.E2E_STEPS_TEST "yes"`, Command: touch("skipped")},
+				{ID: "ignored", Command: "exit 1", ContinueOnError: true},
+				{ID: "next", Needs: []string{"skipped", "ignored"}, If: `{{ ne .Env.E2E_STEPS_TEST "yes" }}`, Command: touch("next")},
+			},
+			wantExist:  []string{"next"},
+			wantAbsent: []string{"skipped"},
+		},
+		{
+			name: "Stop at the failed step without needs",
+			steps: []config.Step{
+				{Name: "broken", Command: "exit 1"},
+				{Name: "following", Command: touch("following")},
+			},
+			wantErr:    "commands: [exit 1] runs error",
+			wantAbsent: []string{"following"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := RunStepsAndWait(tt.steps, time.Minute, nil)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("RunStepsAndWait() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("RunStepsAndWait() error = %v, want %q", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("RunStepsAndWait() took %v, the running steps are not cancelled", elapsed)
+			}
+			for _, name := range tt.wantExist {
+				if _, err := os.Stat(marker(name)); err != nil {
+					t.Errorf("step %s is not run: %v", name, err)
+				}
+			}
+			for _, name := range tt.wantAbsent {
+				if _, err := os.Stat(marker(name)); err == nil {
+					t.Errorf("step %s should not finish", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	ctlwait "k8s.io/kubectl/pkg/cmd/wait"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+const (
+	// maxQuotedBody is the max length of the response body quoted in the error of the http wait.
+	maxQuotedBody = 1024
+	// maxQuotedLines is the max number of the last lines quoted in the error of the log wait, for each log file.
+	maxQuotedLines = 20
+)
+
+// runWait waits for the condition of the wait to be met within the timeout.
+func runWait(ctx context.Context, cluster *util.K8sClusterInfo, wait *config.Wait, timeout time.Duration) error {
+	if !wait.IsKubernetes() {
+		return waitOnHost(ctx, cluster, wait, timeout)
+	}
+	if cluster == nil {
+		return fmt.Errorf("waiting for resource %s needs a kubernetes cluster", wait.Resource)
+	}
+	options, err := getWaitOptions(cluster, wait)
+	if err != nil {
+		return err
+	}
+	options.Timeout = timeout
+	// RunWait doesn't take the context, the condition watching the resources is stopped when it's cancelled,
+	// the goroutine of the `create` condition keeps polling until the timeout at the latest
+	conditionFn := options.ConditionFn
+	options.ConditionFn = func(waitCtx context.Context, info *resource.Info, o *ctlwait.WaitOptions) (runtime.Object, bool, error) {
+		waitCtx, cancel := context.WithCancel(waitCtx)
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+		return conditionFn(waitCtx, info, o)
+	}
+	waited := make(chan error, 1)
+	go func() {
+		waited <- options.RunWait()
+	}()
+	select {
+	case err := <-waited:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitForEnvironment waits for the conditions of `setup.wait` one by one, within the timeout left by the setup.
+func waitForEnvironment(waits []config.Wait, timeout time.Duration, cluster *util.K8sClusterInfo) error {
+	timeBefore := time.Now()
+	for idx := range waits {
+		wait := waits[idx]
+		logger.Log.Infof("waiting for %+v", wait)
+		if err := runWait(context.Background(), cluster, &wait, NewTimeout(timeBefore, timeout)); err != nil {
+			return fmt.Errorf("setup waits error: %v", err)
+		}
+		logger.Log.Infof("wait %+v condition met", wait)
+	}
+	return nil
+}
+
+// waitOnHost checks the tcp, http, command or log wait from the host until it succeeds,
+// the cluster is only used to find the pods of the resource in the log wait.
+func waitOnHost(ctx context.Context, cluster *util.K8sClusterInfo, wait *config.Wait, timeout time.Duration) error {
+	interval, err := wait.GetInterval()
+	if err != nil {
+		return fmt.Errorf("the interval of the wait error: %v", err)
+	}
+
+	var target string
+	var check func(ctx context.Context) error
+	switch {
+	case wait.TCP != "":
+		target = "tcp " + util.ExpandEnv(wait.TCP)
+		check = func(ctx context.Context) error { return checkTCP(ctx, util.ExpandEnv(wait.TCP)) }
+	case wait.HTTP != "":
+		target = "http " + util.ExpandEnv(wait.HTTP)
+		check = func(ctx context.Context) error { return checkHTTP(ctx, wait) }
+	case wait.Log != nil:
+		tail, err := newLogTail(cluster, wait.Log)
+		if err != nil {
+			return err
+		}
+		target = tail.target
+		check = func(context.Context) error { return tail.check() }
+	default:
+		target = "command " + wait.Command
+		check = func(ctx context.Context) error { return checkCommand(ctx, wait.Command) }
+	}
+
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for {
+		// every check should succeed within the interval, and it's stopped at the deadline if it hangs
+		remaining := time.Until(deadline)
+		checkCtx, cancel := context.WithTimeout(ctx, min(interval, remaining))
+		err := check(checkCtx)
+		cutOff := checkCtx.Err() != nil
+		cancel()
+		if err == nil {
+			return nil
+		}
+		// report the error of the previous check, rather than the one cut off by the deadline
+		if lastErr == nil || !cutOff || remaining >= interval {
+			lastErr = err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("wait for %s timeout after %v, last error: %v", target, timeout, lastErr)
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func checkTCP(ctx context.Context, address string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkHTTP sends the request of the wait, and checks the status and the body of the response.
+func checkHTTP(ctx context.Context, wait *config.Wait) error {
+	method := http.MethodGet
+	if wait.Method != "" {
+		method = strings.ToUpper(wait.Method)
+	}
+	var body io.Reader
+	if wait.Body != "" {
+		body = strings.NewReader(util.ExpandEnv(wait.Body))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, util.ExpandEnv(wait.HTTP), body)
+	if err != nil {
+		return err
+	}
+	for key, value := range wait.Headers {
+		req.Header.Set(key, util.ExpandEnv(value))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if wait.ExpectedStatus != 0 && resp.StatusCode != wait.ExpectedStatus {
+		return fmt.Errorf("unexpected status %s, expected %d, body: %s", resp.Status, wait.ExpectedStatus, quoteBody(content))
+	}
+	if wait.ExpectedStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		return fmt.Errorf("unexpected status %s, body: %s", resp.Status, quoteBody(content))
+	}
+	if wait.ExpectedBody != "" {
+		expected, err := regexp.Compile(wait.ExpectedBody)
+		if err != nil {
+			return fmt.Errorf("invalid expected body %s: %v", wait.ExpectedBody, err)
+		}
+		if !expected.Match(content) {
+			return fmt.Errorf("body doesn't match %s: %s", wait.ExpectedBody, quoteBody(content))
+		}
+	}
+	return nil
+}
+
+// quoteBody quotes the beginning of the response body in the errors.
+func quoteBody(content []byte) string {
+	if len(content) > maxQuotedBody {
+		return fmt.Sprintf("%q...", content[:maxQuotedBody])
+	}
+	return fmt.Sprintf("%q", content)
+}
+
+func checkCommand(ctx context.Context, command string) error {
+	if _, stderr, err := util.ExecuteCommandContext(ctx, command); err != nil {
+		return fmt.Errorf("%v, stderr: %s", err, stderr)
+	}
+	return nil
+}
+
+// logTail follows the log files captured by the setup, and counts the lines matching the pattern of the log wait.
+type logTail struct {
+	target  string
+	wait    *config.LogWait
+	pattern *regexp.Regexp
+	files   func() ([]string, error)
+	read    map[string]*logTailFile
+}
+
+// logTailFile is the state of a followed log file.
+type logTailFile struct {
+	offset    int64
+	matched   int
+	lastLines []string
+}
+
+func newLogTail(cluster *util.K8sClusterInfo, wait *config.LogWait) (*logTail, error) {
+	pattern, err := regexp.Compile(wait.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log pattern %s: %v", wait.Pattern, err)
+	}
+	tail := &logTail{wait: wait, pattern: pattern, read: make(map[string]*logTailFile)}
+	if wait.Service != "" {
+		service := util.ExpandEnv(wait.Service)
+		tail.target = "log of " + service
+		tail.files = func() ([]string, error) {
+			return []string{logFollower.LogFile(filepath.Join(service, "std.log"))}, nil
+		}
+		return tail, nil
+	}
+	if cluster == nil {
+		return nil, fmt.Errorf("waiting for the log of resource %s needs a kubernetes cluster", wait.Resource)
+	}
+	tail.target = "log of " + util.ExpandEnv(wait.Resource)
+	tail.files = func() ([]string, error) { return podLogFiles(cluster, wait) }
+	return tail, nil
+}
+
+// check reads the new lines of the log files, the error quotes the last lines of them if the lines are not enough.
+func (t *logTail) check() error {
+	files, err := t.files()
+	if err != nil {
+		return err
+	}
+	matched := 0
+	var captured []string
+	for _, file := range files {
+		read, ok := t.read[file]
+		if !ok {
+			read = &logTailFile{}
+			t.read[file] = read
+		}
+		if err := t.readFile(file, read); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		matched += read.matched
+		captured = append(captured, file)
+	}
+	if matched >= t.wait.GetOccurrences() {
+		return nil
+	}
+	if len(captured) == 0 {
+		return fmt.Errorf("no log is captured yet")
+	}
+
+	var quoted strings.Builder
+	for _, file := range captured {
+		fmt.Fprintf(&quoted, "\n==> last lines of %s <==", file)
+		for _, line := range t.read[file].lastLines {
+			quoted.WriteString("\n" + line)
+		}
+	}
+	return fmt.Errorf("%d of %d lines matched %s%s", matched, t.wait.GetOccurrences(), t.wait.Pattern, quoted.String())
+}
+
+// readFile reads the complete lines written since the last read.
+func (t *logTail) readFile(file string, read *logTailFile) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// the file is built again
+	if info.Size() < read.offset {
+		*read = logTailFile{}
+	}
+	if _, err := f.Seek(read.offset, io.SeekStart); err != nil {
+		return err
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	// the last line is still being written
+	end := bytes.LastIndexByte(content, '\n')
+	if end < 0 {
+		return nil
+	}
+	read.offset += int64(end + 1)
+
+	for _, line := range strings.Split(string(content[:end]), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if t.pattern.MatchString(line) {
+			read.matched++
+		}
+		read.lastLines = append(read.lastLines, line)
+	}
+	if len(read.lastLines) > maxQuotedLines {
+		read.lastLines = read.lastLines[len(read.lastLines)-maxQuotedLines:]
+	}
+	return nil
+}
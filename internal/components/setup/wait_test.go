@@ -0,0 +1,173 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func TestWaitOnHost(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/graphql":
+			body, _ := io.ReadAll(r.Body)
+			if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			// the data is ready after the second request
+			if atomic.AddInt32(&requests, 1) < 2 {
+				_, _ = w.Write([]byte(`{"data":null}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"version":"10.0.0"},"query":` + string(body) + `}`))
+		case "/hanging":
+			<-r.Context().Done()
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		wait    config.Wait
+		wantErr string
+	}{
+		{
+			name: "HTTP request until the body matches",
+			wait: config.Wait{
+				HTTP:         server.URL + "/graphql",
+				Method:       "post",
+				Headers:      map[string]string{"Content-Type": "application/json"},
+				Body:         `{"query":"{ version }"}`,
+				ExpectedBody: `"version":"\d+\.\d+\.\d+"`,
+				Interval:     "10ms",
+			},
+		},
+		{
+			name: "HTTP expected status",
+			wait: config.Wait{HTTP: server.URL + "/missing", ExpectedStatus: http.StatusNotFound},
+		},
+		{
+			name:    "HTTP unexpected status",
+			wait:    config.Wait{HTTP: server.URL + "/missing", Interval: "100ms"},
+			wantErr: `unexpected status 404 Not Found, body: "not found"`,
+		},
+		{
+			name:    "HTTP body not matched",
+			wait:    config.Wait{HTTP: server.URL + "/missing", ExpectedStatus: http.StatusNotFound, ExpectedBody: "^found", Interval: "100ms"},
+			wantErr: `body doesn't match ^found: "not found"`,
+		},
+		{
+			name:    "HTTP hanging beyond the interval",
+			wait:    config.Wait{HTTP: server.URL + "/hanging", Interval: "100ms"},
+			wantErr: "context deadline exceeded",
+		},
+		{
+			name: "TCP",
+			wait: config.Wait{TCP: strings.TrimPrefix(server.URL, "http://")},
+		},
+		{
+			name:    "Command",
+			wait:    config.Wait{Command: "exit 1", Interval: "100ms"},
+			wantErr: "wait for command exit 1 timeout after 300ms",
+		},
+		{
+			name:    "Command hanging beyond the interval",
+			wait:    config.Wait{Command: "sleep 10", Interval: "100ms"},
+			wantErr: "wait for command sleep 10 timeout after 300ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := waitOnHost(context.Background(), nil, &tt.wait, 300*time.Millisecond)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("waitOnHost() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("waitOnHost() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWaitOnHostLog(t *testing.T) {
+	logFollower = util.NewResourceLogFollower(context.Background(), t.TempDir())
+	defer func() { logFollower = nil }()
+
+	writer, err := logFollower.BuildLogWriter("oap/std.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	go func() {
+		for _, content := range []string{"booting\n", "module core started\nmodule query ", "started\n", "ready"} {
+			time.Sleep(20 * time.Millisecond)
+			_, _ = writer.WriteString(content)
+		}
+	}()
+
+	tests := []struct {
+		name    string
+		wait    config.LogWait
+		wantErr string
+	}{
+		{
+			name: "Lines matched",
+			wait: config.LogWait{Service: "oap", Pattern: `module \w+ started`, Occurrences: 2},
+		},
+		{
+			name:    "Lines not enough",
+			wait:    config.LogWait{Service: "oap", Pattern: `module \w+ started`, Occurrences: 3},
+			wantErr: "2 of 3 lines matched module \\w+ started\n==> last lines of " + logFollower.LogFile("oap/std.log") + " <==\nbooting\nmodule core started\nmodule query started",
+		},
+		{
+			name:    "Log not captured",
+			wait:    config.LogWait{Service: "ui", Pattern: "started"},
+			wantErr: "wait for log of ui timeout after 300ms, last error: no log is captured yet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := waitOnHost(context.Background(), nil, &config.Wait{Log: &tt.wait, Interval: "10ms"}, 300*time.Millisecond)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("waitOnHost() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.HasSuffix(err.Error(), tt.wantErr)) {
+				t.Fatalf("waitOnHost() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
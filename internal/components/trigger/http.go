@@ -23,11 +23,11 @@ import (
 	"io"
 	"math"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
 )
 
 type httpAction struct {
@@ -59,7 +59,7 @@ func NewHTTPAction(intervalStr string, times int, url, method, body string, head
 	}
 
 	// there can be env variables in url, say, "http://${GATEWAY_HOST}:${GATEWAY_PORT}/test"
-	url = os.ExpandEnv(url)
+	url = util.ExpandEnv(url)
 
 	return &httpAction{
 		interval:      interval,
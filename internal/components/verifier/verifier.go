@@ -50,7 +50,7 @@ func Verify(actualData, expectedTemplate string) error {
 		return fmt.Errorf("failed to unmarshal actual data: %v", err)
 	}
 
-	tmpl, err := template.New("test").Funcs(funcMap()).Parse(expectedTemplate)
+	tmpl, err := parseTemplate("test", expectedTemplate)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %v", err)
 	}
@@ -72,3 +72,14 @@ func Verify(actualData, expectedTemplate string) error {
 	}
 	return nil
 }
+
+// ParseTemplate parses the expected template with the custom functions without executing it,
+// so syntax errors and unknown functions can be found before any actual data is available.
+func ParseTemplate(name, expectedTemplate string) error {
+	_, err := parseTemplate(name, expectedTemplate)
+	return err
+}
+
+func parseTemplate(name, expectedTemplate string) (*template.Template, error) {
+	return template.New(name).Funcs(funcMap()).Parse(expectedTemplate)
+}
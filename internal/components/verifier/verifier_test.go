@@ -613,3 +613,43 @@ metrics:
 		})
 	}
 }
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name             string
+		expectedTemplate string
+		wantErr          bool
+	}{
+		{
+			name: "should parse template with custom functions",
+			expectedTemplate: `
+metrics:
+{{- contains .metrics }}
+  - name: {{ notEmpty .name }}
+    value: {{ gt .value 0 }}
+{{- end }}
+`,
+		},
+		{
+			name: "should fail with unclosed block",
+			expectedTemplate: `
+metrics:
+{{- contains .metrics }}
+  - name: {{ notEmpty .name }}
+`,
+			wantErr: true,
+		},
+		{
+			name:             "should fail with unknown function",
+			expectedTemplate: `name: {{ notExist .name }}`,
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ParseTemplate("expected.yaml", tt.expectedTemplate); (err != nil) != tt.wantErr {
+				t.Errorf("ParseTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
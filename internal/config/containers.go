@@ -0,0 +1,103 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Container is a container started by the containers env, without a docker compose file.
+type Container struct {
+	Name      string            `yaml:"name"`
+	Image     string            `yaml:"image"`
+	Env       map[string]string `yaml:"env"`
+	Ports     []string          `yaml:"ports"` // the port number, or `<port>/udp` for the udp port
+	Command   []string          `yaml:"command"`
+	Networks  []string          `yaml:"networks"`
+	DependsOn []string          `yaml:"depends-on"`
+	Wait      ContainerWait     `yaml:"wait"`
+}
+
+// ContainerWait is how to check the container is ready, all the tcp ports should be listening if none of them is set.
+type ContainerWait struct {
+	Port    int    `yaml:"port"`    // the port to be listening
+	HTTP    string `yaml:"http"`    // the path to respond 2xx, on `port` or the first tcp port
+	Log     string `yaml:"log"`     // the regex of the log line
+	Command string `yaml:"command"` // the command to exit 0 in the container
+}
+
+// ParseContainerPort parses the container port in the form of `<port>` or `<port>/<protocol>`,
+// the protocol is tcp or udp and defaults to tcp.
+func ParseContainerPort(port string) (number int, protocol string, err error) {
+	number, err = strconv.Atoi(port)
+	protocol = "tcp"
+	if idx := strings.LastIndex(port, "/"); idx >= 0 {
+		number, err = strconv.Atoi(port[:idx])
+		protocol = port[idx+1:]
+	}
+	if err != nil || number <= 0 || number > 65535 {
+		return 0, "", fmt.Errorf("invalid port %q, should be a port number optionally followed by /tcp or /udp", port)
+	}
+	if protocol != "tcp" && protocol != "udp" {
+		return 0, "", fmt.Errorf("unsupported protocol %q of port %q, should be tcp or udp", protocol, port)
+	}
+	return number, protocol, nil
+}
+
+// SortContainers sorts the containers by their dependencies, so every container is started after the ones it depends on.
+func SortContainers(containers []Container) ([]Container, error) {
+	byName := make(map[string]*Container, len(containers))
+	for idx := range containers {
+		if _, ok := byName[containers[idx].Name]; ok {
+			return nil, fmt.Errorf("container %s is declared more than once", containers[idx].Name)
+		}
+		byName[containers[idx].Name] = &containers[idx]
+	}
+
+	sorted := make([]Container, 0, len(containers))
+	visited := make(map[string]bool, len(containers))
+	var visit func(name string, chain []string) error
+	visit = func(name string, chain []string) error {
+		if contains(chain, name) {
+			return fmt.Errorf("containers depend on each other circularly: %s", strings.Join(append(chain, name), " -> "))
+		}
+		if visited[name] {
+			return nil
+		}
+		container := byName[name]
+		for _, dependency := range container.DependsOn {
+			if _, ok := byName[dependency]; !ok {
+				return fmt.Errorf("container %s depends on unknown container %s", name, dependency)
+			}
+			if err := visit(dependency, append(chain, name)); err != nil {
+				return err
+			}
+		}
+		visited[name] = true
+		sorted = append(sorted, *container)
+		return nil
+	}
+	for idx := range containers {
+		if err := visit(containers[idx].Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSortContainers(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []Container
+		want       []string
+		wantErr    string
+	}{
+		{
+			name:       "Keep the declared order without dependencies",
+			containers: []Container{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			want:       []string{"a", "b", "c"},
+		},
+		{
+			name: "Start the dependencies first",
+			containers: []Container{
+				{Name: "ui", DependsOn: []string{"oap"}},
+				{Name: "oap", DependsOn: []string{"banyandb", "zookeeper"}},
+				{Name: "zookeeper"},
+				{Name: "banyandb", DependsOn: []string{"zookeeper"}},
+			},
+			want: []string{"zookeeper", "banyandb", "oap", "ui"},
+		},
+		{
+			name:       "Unknown dependency",
+			containers: []Container{{Name: "oap", DependsOn: []string{"es"}}},
+			wantErr:    "container oap depends on unknown container es",
+		},
+		{
+			name: "Circular dependencies",
+			containers: []Container{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"c"}},
+				{Name: "c", DependsOn: []string{"a"}},
+			},
+			wantErr: "a -> b -> c -> a",
+		},
+		{
+			name:       "Duplicated names",
+			containers: []Container{{Name: "a"}, {Name: "a"}},
+			wantErr:    "container a is declared more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := SortContainers(tt.containers)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SortContainers() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SortContainers() unexpected error: %v", err)
+			}
+			names := make([]string, 0, len(sorted))
+			for _, container := range sorted {
+				names = append(names, container.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("SortContainers() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContainerPort(t *testing.T) {
+	tests := []struct {
+		port         string
+		wantNumber   int
+		wantProtocol string
+		wantErr      bool
+	}{
+		{port: "12800", wantNumber: 12800, wantProtocol: "tcp"},
+		{port: "12800/tcp", wantNumber: 12800, wantProtocol: "tcp"},
+		{port: "8125/udp", wantNumber: 8125, wantProtocol: "udp"},
+		{port: "8125/sctp", wantErr: true},
+		{port: "oap", wantErr: true},
+		{port: "0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.port, func(t *testing.T) {
+			number, protocol, err := ParseContainerPort(tt.port)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseContainerPort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if number != tt.wantNumber || protocol != tt.wantProtocol {
+				t.Errorf("ParseContainerPort() = %d, %s, want %d, %s", number, protocol, tt.wantNumber, tt.wantProtocol)
+			}
+		})
+	}
+}
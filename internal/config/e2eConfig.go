@@ -20,7 +20,9 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
@@ -30,20 +32,27 @@ import (
 
 // E2EConfig corresponds to configuration file e2e.yaml.
 type E2EConfig struct {
-	Setup   Setup   `yaml:"setup"`
-	Cleanup Cleanup `yaml:"cleanup"`
-	Trigger Trigger `yaml:"trigger"`
-	Verify  Verify  `yaml:"verify"`
+	Extends string              `yaml:"extends"`
+	Matrix  map[string][]string `yaml:"matrix"`
+	Setup   Setup               `yaml:"setup"`
+	Cleanup Cleanup             `yaml:"cleanup"`
+	Trigger Trigger             `yaml:"trigger"`
+	Verify  Verify              `yaml:"verify"`
 }
 
 type Setup struct {
-	Env                   string    `yaml:"env"`
-	File                  string    `yaml:"file"`
-	Kubeconfig            string    `yaml:"kubeconfig"`
-	Steps                 []Step    `yaml:"steps"`
-	Timeout               any       `yaml:"timeout"`
-	InitSystemEnvironment string    `yaml:"init-system-environment"`
-	Kind                  KindSetup `yaml:"kind"`
+	Env                   string      `yaml:"env"`
+	File                  string      `yaml:"file"`
+	Kubeconfig            string      `yaml:"kubeconfig"`
+	Steps                 []Step      `yaml:"steps"`
+	Timeout               any         `yaml:"timeout"`
+	InitSystemEnvironment string      `yaml:"init-system-environment"`
+	Kind                  KindSetup   `yaml:"kind"`
+	Containers            []Container `yaml:"containers"`
+	// Compose is the options of the services in the compose file.
+	Compose ComposeSetup `yaml:"compose"`
+	// Waits are the conditions to wait for after the environment and the steps are ready.
+	Waits []Wait `yaml:"wait"`
 
 	timeout time.Duration
 }
@@ -71,7 +80,7 @@ type Cleanup struct {
 
 type CollectConfig struct {
 	On        string        `yaml:"on"`         // always|failure|never, default: failure
-	OutputDir string        `yaml:"output-dir"`  // required when items are configured
+	OutputDir string        `yaml:"output-dir"` // required when items are configured
 	Items     []CollectItem `yaml:"items"`
 }
 
@@ -81,7 +90,7 @@ func (c *CollectConfig) Finalize() {
 		c.On = constant.CollectOnFailure
 	}
 	if c.OutputDir != "" {
-		c.OutputDir = os.ExpandEnv(c.OutputDir)
+		c.OutputDir = util.ExpandEnv(c.OutputDir)
 		c.OutputDir = util.ExpandFilePath(c.OutputDir)
 		c.OutputDir = util.ResolveAbs(c.OutputDir)
 	}
@@ -100,16 +109,93 @@ type CollectItem struct {
 }
 
 type Step struct {
-	Name    string `yaml:"name"`
-	Path    string `yaml:"path"`
-	Command string `yaml:"command"`
-	Waits   []Wait `yaml:"wait"`
+	ID        string    `yaml:"id"`    // the id of the step, which could be needed by the others
+	Needs     []string  `yaml:"needs"` // the ids of the steps to succeed before it starts, they run concurrently if any step needs others
+	Name      string    `yaml:"name"`
+	Path      string    `yaml:"path"`
+	Kustomize string    `yaml:"kustomize"` // the directory of the kustomization, rendered and applied like the manifests
+	Mode      string    `yaml:"mode"`      // how the manifests are applied, create, apply or replace, default is apply
+	Render    string    `yaml:"render"`    // how the manifests are rendered before applied, env or template, not rendered by default
+	Command   string    `yaml:"command"`
+	Helm      *HelmStep `yaml:"helm"`
+	Waits     []Wait    `yaml:"wait"`
+	Includes  []string  `yaml:"includes"`
+	// Background keeps the command running in the background until the cleanup, only for the local env.
+	Background bool `yaml:"background"`
+	// Timeout limits every attempt of the step, besides the timeout of the setup shared by all the steps.
+	Timeout string     `yaml:"timeout"`
+	Retry   *StepRetry `yaml:"retry"`
+	// If is the condition to run the step, a Go template rendered to true or false with the env and matrix values.
+	If string `yaml:"if"`
+	// ContinueOnError doesn't fail the setup when the step fails, and the steps needing it still run.
+	ContinueOnError bool `yaml:"continue-on-error"`
+}
+
+// StepRetry retries the failed step.
+type StepRetry struct {
+	Count    int     `yaml:"count"`    // the max retry count after the first attempt
+	Interval string  `yaml:"interval"` // the interval before the first retry, e.g. 10s, 1m
+	Backoff  float64 `yaml:"backoff"`  // the factor the interval is multiplied by after every retry, default is 1
+}
+
+// HelmStep installs or upgrades a helm release, only for the kind env.
+type HelmStep struct {
+	Chart     string            `yaml:"chart"`     // the chart path, or the chart name in the repo
+	Repo      string            `yaml:"repo"`      // the chart repository url
+	Version   string            `yaml:"version"`   // the chart version, the latest one if it's empty
+	Release   string            `yaml:"release"`   // the release name
+	Namespace string            `yaml:"namespace"` // the namespace of the release, created if it doesn't exist
+	Values    []string          `yaml:"values"`    // the values files
+	Set       map[string]string `yaml:"set"`       // the values set on the command line, the environment variables are expanded
+	NoWait    bool              `yaml:"no-wait"`   // don't wait for the resources of the release to be ready
+}
+
+// resolvePaths resolves the values files and the local chart against the file declaring the step.
+func (h *HelmStep) resolvePaths(baseFile string) {
+	if h == nil {
+		return
+	}
+	for idx := range h.Values {
+		h.Values[idx] = resolvePathList(h.Values[idx], baseFile)
+	}
+	h.Chart = resolveHelmChartPath(h.Repo, h.Chart, baseFile)
+}
+
+// IsHelmChartPath reports whether the chart is written as a local path, rather than the chart name in a repo.
+func IsHelmChartPath(chart string) bool {
+	return strings.HasPrefix(chart, "./") || strings.HasPrefix(chart, "../") || strings.HasSuffix(chart, ".tgz") ||
+		path.IsAbs(chart) || filepath.IsAbs(chart)
+}
+
+// resolveHelmChartPath resolves the local chart against the base file, which is written as a path or exists
+// relative to the base file, the same as helm looks for the local chart before the repo.
+func resolveHelmChartPath(repo, chart, baseFile string) string {
+	if repo != "" || chart == "" || strings.Contains(chart, "://") {
+		return chart
+	}
+	if IsHelmChartPath(chart) || util.PathExist(util.ResolveAbsWithBase(chart, baseFile)) {
+		return resolvePathList(chart, baseFile)
+	}
+	return chart
 }
 
 type KindSetup struct {
 	ImportImages []string         `yaml:"import-images"`
 	ExposePorts  []KindExposePort `yaml:"expose-ports"`
 	NoWait       bool             `yaml:"no-wait"`
+	// IsolateNamespace creates a namespace unique to the run, the objects, releases, waits and exposed ports
+	// without namespace or in the default namespace are put in it.
+	IsolateNamespace bool `yaml:"isolate-namespace"`
+}
+
+type ComposeSetup struct {
+	// Files are the compose files merged over `file` in order, like the multiple `-f` flags of docker compose.
+	Files []string `yaml:"files"`
+	// Profiles are the profiles to enable, the ones in `COMPOSE_PROFILES` are enabled if it's empty.
+	Profiles []string `yaml:"profiles"`
+	// OneShot are the services which run to completion, the setup waits for them to exit with code 0,
+	// besides the ones depended on with the condition `service_completed_successfully`.
+	OneShot []string `yaml:"one-shot"`
 }
 
 type KindExposePort struct {
@@ -127,21 +213,41 @@ type Verify struct {
 
 func (s *Setup) GetFile() string {
 	// expand the file path with system environment
-	file := os.ExpandEnv(s.File)
+	file := util.ExpandEnv(s.File)
 	file = util.ResolveAbs(file)
 	return file
 }
 
+// GetComposeFiles returns the compose file and the ones merged over it.
+func (s *Setup) GetComposeFiles() []string {
+	files := []string{s.GetFile()}
+	for _, file := range s.Compose.Files {
+		files = append(files, util.ResolveAbs(util.ExpandEnv(file)))
+	}
+	return files
+}
+
 func (s *Setup) GetKubeconfig() string {
 	// expand the file path with system environment
-	file := os.ExpandEnv(s.Kubeconfig)
+	file := util.ExpandEnv(s.Kubeconfig)
 	file = util.ResolveAbs(file)
 	return file
 }
 
 type Manifest struct {
-	Path  string `yaml:"path"`
-	Waits []Wait `yaml:"wait"`
+	Path      string `yaml:"path"`
+	Kustomize string `yaml:"kustomize"`
+	Mode      string `yaml:"mode"`
+	Render    string `yaml:"render"`
+	Waits     []Wait `yaml:"wait"`
+}
+
+// GetMode returns how the manifests are applied, the server-side apply by default.
+func (m *Manifest) GetMode() string {
+	if m.Mode == "" {
+		return constant.ManifestApply
+	}
+	return m.Mode
 }
 
 type Run struct {
@@ -154,6 +260,64 @@ type Wait struct {
 	Resource      string `yaml:"resource"`
 	LabelSelector string `yaml:"label-selector"`
 	For           string `yaml:"for"`
+
+	// the waits below don't need a kubernetes cluster
+	TCP     string `yaml:"tcp"`     // host:port to connect
+	HTTP    string `yaml:"http"`    // url to respond 2xx, or the expected-status
+	Command string `yaml:"command"` // command to exit 0
+
+	// Log waits for the lines in the log of a service, or of the pods in the kubernetes cluster.
+	Log *LogWait `yaml:"log"`
+
+	// the request and the expected response of the http wait
+	Method         string            `yaml:"method"` // default is GET
+	Headers        map[string]string `yaml:"headers"`
+	Body           string            `yaml:"body"`
+	ExpectedStatus int               `yaml:"expected-status"` // any 2xx status by default
+	ExpectedBody   string            `yaml:"expected-body"`   // the regex the response body should match
+
+	// Interval is the interval between the checks of the tcp, http, command or log wait, default is 1s.
+	Interval string `yaml:"interval"`
+}
+
+// GetInterval returns the interval between the checks of the wait.
+func (w *Wait) GetInterval() (time.Duration, error) {
+	if w.Interval == "" {
+		return constant.DefaultWaitInterval, nil
+	}
+	interval, err := time.ParseDuration(w.Interval)
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("should be > 0, but was %s", interval)
+	}
+	return interval, nil
+}
+
+// IsKubernetes returns whether the wait is a `kubectl wait` on the resources.
+func (w *Wait) IsKubernetes() bool {
+	return w.TCP == "" && w.HTTP == "" && w.Command == "" && w.Log == nil
+}
+
+// LogWait waits for the lines matching the pattern in the log captured by the setup.
+type LogWait struct {
+	// Service is the compose service, the container or the background step, whose log is `<log-dir>/<service>/std.log`.
+	Service string `yaml:"service"`
+	// Resource is the kubernetes pod, or the workload whose pods are checked, such as `deployment/oap`.
+	Resource  string `yaml:"resource"`
+	Namespace string `yaml:"namespace"`
+
+	Pattern     string `yaml:"pattern"`     // the regex the lines should match
+	Occurrences int    `yaml:"occurrences"` // how many lines should match, default is 1
+}
+
+// GetOccurrences returns how many lines should match the pattern.
+func (l *LogWait) GetOccurrences() int {
+	if l.Occurrences <= 0 {
+		return 1
+	}
+	return l.Occurrences
 }
 
 type Trigger struct {
@@ -183,6 +347,11 @@ type ReusingCases struct {
 	Cases []VerifyCase `yaml:"cases"`
 }
 
+// ReusingSteps corresponds to the steps file included by the `includes` of a setup step.
+type ReusingSteps struct {
+	Steps []Step `yaml:"steps"`
+}
+
 // GetActual resolves the absolute file path of the actual data file.
 func (v *VerifyCase) GetActual() string {
 	return util.ResolveAbs(v.Actual)
@@ -208,6 +377,8 @@ func parseInterval(retryInterval any, name string) (time.Duration, error) {
 		if interval, err = time.ParseDuration(itv); err != nil {
 			return 0, err
 		}
+	case nil:
+		interval = 0
 	default:
 		return 0, fmt.Errorf("failed to parse %v: %v", name, retryInterval)
 	}
@@ -0,0 +1,269 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// configLayer is a config file in the extends chain.
+type configLayer struct {
+	file string
+	data []byte
+	// extends is the absolute path of the file this one extends, empty if it does not extend any file.
+	extends string
+}
+
+// readConfigLayer reads the config file and resolves the file it extends.
+func readConfigLayer(file string) (*configLayer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	conf := struct {
+		Extends string `yaml:"extends"`
+	}{}
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return nil, err
+	}
+	return &configLayer{file: file, data: data, extends: resolveExtends(conf.Extends, file)}, nil
+}
+
+// resolveExtends resolves the absolute path of the extended file, relative paths are resolved against the file extending it.
+func resolveExtends(extends, file string) string {
+	if extends == "" {
+		return ""
+	}
+	return util.ResolveAbsWithBase(util.ExpandFilePath(util.ExpandEnv(extends)), file)
+}
+
+// loadConfigChain reads the config file and all the files it extends, from the farthest base to the config file itself.
+func loadConfigChain(file string) ([]*configLayer, error) {
+	var chain []*configLayer
+	visited := make(map[string]bool)
+	for file != "" {
+		if visited[file] {
+			return nil, fmt.Errorf("config file %s is extended circularly", file)
+		}
+		visited[file] = true
+
+		if !util.PathExist(file) {
+			return nil, fmt.Errorf("e2e config file %s not exist", file)
+		}
+		layer, err := readConfigLayer(file)
+		if err != nil {
+			return nil, fmt.Errorf("read e2e config file %s error: %s", file, err)
+		}
+		chain = append([]*configLayer{layer}, chain...)
+		file = layer.extends
+	}
+	return chain, nil
+}
+
+// replaceTag marks a value replacing the one of the base file, rather than being merged into it.
+const replaceTag = "!replace"
+
+// mergeConfigChain deep-merges the config files of the chain into one, the later files override the former ones:
+//   - maps are merged key by key,
+//   - lists are appended, the items of the base file come first,
+//   - other values are replaced, except null which keeps the base value,
+//   - the values tagged by `!replace` replace the base values as a whole.
+//
+// The relative paths in the base files are resolved against the files declaring them,
+// so that they still point to the same files after being merged into the config file.
+// The files are merged as YAML nodes, so the values are decoded the same way as they are in a single file.
+func mergeConfigChain(chain []*configLayer) ([]byte, error) {
+	if len(chain) == 1 {
+		return chain[0].data, nil
+	}
+
+	var merged *yaml3.Node
+	for idx, layer := range chain {
+		var doc yaml3.Node
+		if err := yaml3.Unmarshal(layer.data, &doc); err != nil {
+			return nil, fmt.Errorf("unmarshal e2e config file %s error: %s", layer.file, err)
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		conf := doc.Content[0]
+		removeConfigKey(conf, "extends")
+		if idx < len(chain)-1 {
+			resolveConfigPaths(conf, layer.file)
+		}
+		merged = mergeConfigNode(merged, conf)
+	}
+	if merged == nil {
+		return nil, nil
+	}
+	removeReplaceTags(merged)
+	return yaml3.Marshal(merged)
+}
+
+func mergeConfigNode(base, override *yaml3.Node) *yaml3.Node {
+	if override == nil || (override.Kind == yaml3.ScalarNode && override.ShortTag() == "!!null") {
+		return base
+	}
+	if base == nil || override.Tag == replaceTag {
+		return override
+	}
+
+	switch {
+	case base.Kind == yaml3.MappingNode && override.Kind == yaml3.MappingNode:
+		for i := 0; i+1 < len(override.Content); i += 2 {
+			key, value := override.Content[i], override.Content[i+1]
+			if j := configKeyIndex(base, key.Value); j >= 0 {
+				base.Content[j+1] = mergeConfigNode(base.Content[j+1], value)
+			} else {
+				base.Content = append(base.Content, key, value)
+			}
+		}
+		return base
+	case base.Kind == yaml3.SequenceNode && override.Kind == yaml3.SequenceNode:
+		base.Content = append(base.Content, override.Content...)
+		return base
+	default:
+		return override
+	}
+}
+
+// removeReplaceTags removes the `!replace` tags after merging, so the values are decoded as if they're not tagged.
+func removeReplaceTags(node *yaml3.Node) {
+	if node.Tag == replaceTag {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		removeReplaceTags(child)
+	}
+}
+
+// configKeyIndex returns the index of the key in the mapping node, or -1 if it's not found.
+func configKeyIndex(node *yaml3.Node, key string) int {
+	if node.Kind != yaml3.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeConfigKey(node *yaml3.Node, key string) {
+	if i := configKeyIndex(node, key); i >= 0 {
+		node.Content = append(node.Content[:i], node.Content[i+2:]...)
+	}
+}
+
+// configPathKeys are the keys holding file paths, "[]" stands for every item of a list.
+var configPathKeys = [][]string{
+	{"setup", "file"},
+	{"setup", "kubeconfig"},
+	{"setup", "init-system-environment"},
+	{"setup", "compose", "files", "[]"},
+	{"setup", "steps", "[]", "path"},
+	{"setup", "steps", "[]", "kustomize"},
+	{"setup", "steps", "[]", "helm", "values", "[]"},
+	{"setup", "steps", "[]", "includes", "[]"},
+	{"cleanup", "collect", "output-dir"},
+	{"verify", "cases", "[]", "expected"},
+	{"verify", "cases", "[]", "actual"},
+	{"verify", "cases", "[]", "includes", "[]"},
+}
+
+func resolveConfigPaths(conf *yaml3.Node, baseFile string) {
+	for _, keys := range configPathKeys {
+		resolveConfigPath(conf, keys, baseFile)
+	}
+	resolveHelmChartPaths(conf, baseFile)
+}
+
+// resolveHelmChartPaths resolves the local charts of the helm steps, which depend on the `repo` of the same step.
+func resolveHelmChartPaths(conf *yaml3.Node, baseFile string) {
+	setup := configValue(conf, "setup")
+	steps := configValue(setup, "steps")
+	if steps == nil || steps.Kind != yaml3.SequenceNode {
+		return
+	}
+	for _, step := range steps.Content {
+		helm := configValue(step, "helm")
+		chart := configValue(helm, "chart")
+		if chart == nil || chart.Kind != yaml3.ScalarNode || chart.ShortTag() != "!!str" {
+			continue
+		}
+		repo := ""
+		if node := configValue(helm, "repo"); node != nil {
+			repo = node.Value
+		}
+		chart.Value = resolveHelmChartPath(repo, chart.Value, baseFile)
+	}
+}
+
+// configValue returns the value of the key in the mapping node, or nil if it's not found.
+func configValue(node *yaml3.Node, key string) *yaml3.Node {
+	if node == nil {
+		return nil
+	}
+	if i := configKeyIndex(node, key); i >= 0 {
+		return node.Content[i+1]
+	}
+	return nil
+}
+
+func resolveConfigPath(node *yaml3.Node, keys []string, baseFile string) {
+	if len(keys) == 0 {
+		if node.Kind == yaml3.ScalarNode && node.ShortTag() == "!!str" {
+			node.Value = resolvePathList(node.Value, baseFile)
+		}
+		return
+	}
+
+	switch {
+	case node.Kind == yaml3.SequenceNode && keys[0] == "[]":
+		for _, item := range node.Content {
+			resolveConfigPath(item, keys[1:], baseFile)
+		}
+	case node.Kind == yaml3.MappingNode:
+		if i := configKeyIndex(node, keys[0]); i >= 0 {
+			resolveConfigPath(node.Content[i+1], keys[1:], baseFile)
+		}
+	}
+}
+
+// resolvePathList resolves the comma separated paths against the base file,
+// the paths starting with environment variables or `~` are kept as they are.
+func resolvePathList(paths, baseFile string) string {
+	list := strings.Split(paths, ",")
+	for idx, p := range list {
+		p = strings.TrimSpace(p)
+		if p == "" || path.IsAbs(p) || strings.HasPrefix(p, "$") || strings.HasPrefix(p, "~") {
+			continue
+		}
+		list[idx] = util.ResolveAbsWithBase(p, baseFile)
+	}
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,205 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestMergeConfigChain(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"e2e.yaml": `
+extends: base/base.yaml
+setup:
+  file: docker-compose.yml
+trigger:
+  times: 5
+verify:
+  retry:
+  cases:
+    - query: echo bar
+      expected: expected.yaml
+`,
+		"base/base.yaml": `
+extends: ../common/common.yaml
+setup:
+  env: compose
+  compose:
+    files:
+      - compose.override.yaml
+  steps:
+    - name: install
+      path: manifests/a.yaml,/abs/b.yaml,${DIR}/c.yaml
+    - name: oap
+      helm:
+        chart: ./charts/oap
+        release: oap
+    - name: skywalking
+      helm:
+        chart: skywalking
+        repo: oci://registry-1.docker.io/apache
+        release: skywalking
+    - name: nginx
+      helm:
+        chart: bitnami/nginx
+        release: nginx
+trigger:
+  action: http
+  times: 3
+verify:
+  cases:
+    - query: echo foo
+      expected: expected.yaml
+`,
+		"common/common.yaml": `
+cleanup:
+  on: always
+verify:
+  retry:
+    count: 10
+    interval: 10s
+`,
+	})
+
+	chain, err := loadConfigChain(filepath.Join(dir, "e2e.yaml"))
+	if err != nil {
+		t.Fatalf("loadConfigChain() error = %v", err)
+	}
+	data, err := mergeConfigChain(chain)
+	if err != nil {
+		t.Fatalf("mergeConfigChain() error = %v", err)
+	}
+	var got E2EConfig
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("failed to unmarshal the merged config: %v", err)
+	}
+
+	want := E2EConfig{
+		Setup: Setup{
+			Env:     "compose",
+			File:    "docker-compose.yml",
+			Compose: ComposeSetup{Files: []string{filepath.Join(dir, "base", "compose.override.yaml")}},
+			Steps: []Step{
+				{
+					Name: "install",
+					Path: strings.Join([]string{filepath.Join(dir, "base", "manifests/a.yaml"), "/abs/b.yaml", "${DIR}/c.yaml"}, ","),
+				},
+				{Name: "oap", Helm: &HelmStep{Chart: filepath.Join(dir, "base", "charts/oap"), Release: "oap"}},
+				{Name: "skywalking", Helm: &HelmStep{Chart: "skywalking", Repo: "oci://registry-1.docker.io/apache", Release: "skywalking"}},
+				{Name: "nginx", Helm: &HelmStep{Chart: "bitnami/nginx", Release: "nginx"}},
+			},
+		},
+		Cleanup: Cleanup{On: "always"},
+		Trigger: Trigger{Action: "http", Times: 5},
+		Verify: Verify{
+			RetryStrategy: VerifyRetryStrategy{Count: 10, Interval: "10s"},
+			Cases: []VerifyCase{
+				{Query: "echo foo", Expected: filepath.Join(dir, "base", "expected.yaml")},
+				{Query: "echo bar", Expected: "expected.yaml"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeConfigChain() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMergeConfigChainReplace(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"e2e.yaml": `
+extends: base.yaml
+setup:
+  init-system-environment: ""
+  steps: !replace
+    - name: install
+      command: make install
+trigger: !replace
+  action: http
+verify:
+  retry:
+    count: 0
+  cases: !replace []
+`,
+		"base.yaml": `
+setup:
+  env: compose
+  init-system-environment: env
+  steps:
+    - name: build
+      command: make build
+trigger:
+  action: http
+  interval: 3s
+  times: 3
+verify:
+  retry:
+    count: 10
+    interval: 10s
+  cases:
+    - query: echo foo
+      expected: expected.yaml
+`,
+	})
+
+	chain, err := loadConfigChain(filepath.Join(dir, "e2e.yaml"))
+	if err != nil {
+		t.Fatalf("loadConfigChain() error = %v", err)
+	}
+	data, err := mergeConfigChain(chain)
+	if err != nil {
+		t.Fatalf("mergeConfigChain() error = %v", err)
+	}
+	if strings.Contains(string(data), replaceTag) {
+		t.Errorf("mergeConfigChain() keeps the %s tags:\n%s", replaceTag, data)
+	}
+	var got E2EConfig
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("failed to unmarshal the merged config: %v", err)
+	}
+
+	want := E2EConfig{
+		Setup: Setup{
+			Env:   "compose",
+			Steps: []Step{{Name: "install", Command: "make install"}},
+		},
+		Trigger: Trigger{Action: "http"},
+		Verify: Verify{
+			RetryStrategy: VerifyRetryStrategy{Count: 0, Interval: "10s"},
+			Cases:         []VerifyCase{},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeConfigChain() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadConfigChainCircularly(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"e2e.yaml":  "extends: base.yaml",
+		"base.yaml": "extends: ./e2e.yaml",
+	})
+	_, err := loadConfigChain(filepath.Join(dir, "e2e.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "extended circularly") {
+		t.Errorf("loadConfigChain() error = %v, want circular extends error", err)
+	}
+}
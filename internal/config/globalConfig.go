@@ -22,9 +22,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/apache/skywalking-infra-e2e/internal/components/verifier"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
@@ -35,6 +37,8 @@ import (
 type GlobalE2EConfig struct {
 	Error     error
 	E2EConfig E2EConfig
+	// Matrix is the selected matrix combination, nil if the config has no matrix.
+	Matrix MatrixCombination
 }
 
 var GlobalConfig GlobalE2EConfig
@@ -55,9 +59,21 @@ func ReadGlobalConfigFile() {
 		return
 	}
 
-	data, err := os.ReadFile(util.CfgFile)
+	cfgAbsPath, err := filepath.Abs(util.CfgFile)
 	if err != nil {
-		GlobalConfig.Error = fmt.Errorf("read e2e config file %s error: %s", util.CfgFile, err)
+		GlobalConfig.Error = fmt.Errorf("resolve e2e config file %s error: %s", util.CfgFile, err)
+		return
+	}
+
+	// merge the config files it extends into it
+	chain, err := loadConfigChain(cfgAbsPath)
+	if err != nil {
+		GlobalConfig.Error = err
+		return
+	}
+	data, err := mergeConfigChain(chain)
+	if err != nil {
+		GlobalConfig.Error = err
 		return
 	}
 
@@ -66,14 +82,39 @@ func ReadGlobalConfigFile() {
 		return
 	}
 
+	// override the keys by the command line and environment variables
+	if err := applyOverrides(&GlobalConfig.E2EConfig); err != nil {
+		GlobalConfig.Error = err
+		return
+	}
+
+	// export the variables of the matrix combination before they are used
+	if GlobalConfig.Matrix, err = selectMatrixCombination(&GlobalConfig.E2EConfig); err != nil {
+		GlobalConfig.Error = err
+		return
+	}
+
+	// convert setup
+	if err := convertSetup(&GlobalConfig.E2EConfig.Setup); err != nil {
+		GlobalConfig.Error = err
+		return
+	}
+
 	// convert verify
 	if err := convertVerify(&GlobalConfig.E2EConfig.Verify); err != nil {
 		GlobalConfig.Error = err
 		return
 	}
 
+	// parse the expected templates, so that mistakes in them fail before setting up the environment
+	if err := parseExpectedTemplates(&GlobalConfig.E2EConfig.Verify); err != nil {
+		GlobalConfig.Error = err
+		return
+	}
+
 	if err := GlobalConfig.E2EConfig.Setup.Finalize(); err != nil {
 		GlobalConfig.Error = err
+		return
 	}
 
 	GlobalConfig.Error = nil
@@ -82,6 +123,72 @@ func ReadGlobalConfigFile() {
 	}
 }
 
+func convertSetup(setup *Setup) error {
+	cfgAbsPath, _ := filepath.Abs(util.CfgFile)
+	steps, err := convertSteps(setup.Steps, cfgAbsPath, []string{cfgAbsPath})
+	if err != nil {
+		return err
+	}
+	if _, err := StepDependencies(steps); err != nil {
+		return err
+	}
+	setup.Steps = steps
+	return nil
+}
+
+// convertSteps expands the steps including the reusing steps files, the chain is the files including the steps,
+// to detect the include cycles.
+func convertSteps(steps []Step, baseFile string, chain []string) ([]Step, error) {
+	result := make([]Step, 0, len(steps))
+	for idx := range steps {
+		step := steps[idx]
+		if len(step.Includes) == 0 {
+			result = append(result, step)
+			continue
+		}
+		if step.Path != "" || step.Kustomize != "" || step.Command != "" || step.Helm != nil || len(step.Waits) > 0 {
+			return nil, fmt.Errorf("includes and path/kustomize/command/helm/wait only support selecting one of them in a step")
+		}
+
+		for _, include := range step.Includes {
+			includePath := util.ResolveAbsWithBase(include, baseFile)
+			for _, file := range chain {
+				if file == includePath {
+					return nil, fmt.Errorf("reuse step config file %s is included circularly: %s -> %s",
+						includePath, strings.Join(chain, " -> "), includePath)
+				}
+			}
+
+			if !util.PathExist(includePath) {
+				return nil, fmt.Errorf("reuse step config file %s not exist", includePath)
+			}
+
+			data, err := os.ReadFile(includePath)
+			if err != nil {
+				return nil, fmt.Errorf("reuse step config file %s error: %s", includePath, err)
+			}
+
+			r := &ReusingSteps{}
+			if err := yaml.Unmarshal(data, r); err != nil {
+				return nil, fmt.Errorf("unmarshal reuse step config file %s error: %s", includePath, err)
+			}
+
+			// using include file path as base path to resolve the manifest paths
+			for i := range r.Steps {
+				r.Steps[i].Path = resolvePathList(r.Steps[i].Path, includePath)
+				r.Steps[i].Kustomize = resolvePathList(r.Steps[i].Kustomize, includePath)
+				r.Steps[i].Helm.resolvePaths(includePath)
+			}
+			included, err := convertSteps(r.Steps, includePath, append(chain[:len(chain):len(chain)], includePath))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, included...)
+		}
+	}
+	return result, nil
+}
+
 func convertVerify(verify *Verify) error {
 	// convert cases
 	result := make([]VerifyCase, 0)
@@ -140,3 +247,31 @@ func convertSingleCase(verifyCase *VerifyCase, baseFile string) ([]VerifyCase, e
 	}
 	return result, nil
 }
+
+// parseExpectedTemplates parses the expected template of every case with the verifier functions,
+// each expected file is only parsed once even if it's shared by multiple cases.
+func parseExpectedTemplates(verify *Verify) error {
+	parsed := make(map[string]bool)
+	var errs []string
+	for idx := range verify.Cases {
+		expected := verify.Cases[idx].GetExpected()
+		if expected == "" || parsed[expected] {
+			continue
+		}
+		parsed[expected] = true
+
+		content, err := util.ReadFileContent(expected)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("read expected file %s error: %v", expected, err))
+			continue
+		}
+		if err := verifier.ParseTemplate(expected, content); err != nil {
+			errs = append(errs, fmt.Sprintf("parse expected file error: %v", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("some expected files are invalid:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
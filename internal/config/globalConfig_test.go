@@ -0,0 +1,78 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConvertSteps(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"steps/es.yaml": `
+steps:
+  - name: install es operator
+    path: manifests/operator.yaml,/abs/crds.yaml
+    wait:
+      - resource: pod
+        for: condition=Ready
+  - includes:
+      - oap.yaml
+`,
+		"steps/oap.yaml": `
+steps:
+  - name: wait for oap
+    command: kubectl wait --for=condition=Ready pod -l app=oap
+`,
+		"steps/cycle.yaml": `
+steps:
+  - includes:
+      - cycle.yaml
+`,
+	})
+	cfgFile := filepath.Join(dir, "e2e.yaml")
+
+	steps, err := convertSteps([]Step{
+		{Name: "first", Command: "echo first"},
+		{Includes: []string{"steps/es.yaml"}},
+		{Name: "last", Path: "manifests/last.yaml"},
+	}, cfgFile, []string{cfgFile})
+	if err != nil {
+		t.Fatalf("convertSteps() error = %v", err)
+	}
+	want := []Step{
+		{Name: "first", Command: "echo first"},
+		{
+			Name:  "install es operator",
+			Path:  filepath.Join(dir, "steps/manifests/operator.yaml") + ",/abs/crds.yaml",
+			Waits: []Wait{{Resource: "pod", For: "condition=Ready"}},
+		},
+		{Name: "wait for oap", Command: "kubectl wait --for=condition=Ready pod -l app=oap"},
+		{Name: "last", Path: "manifests/last.yaml"},
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("convertSteps() = %+v, want %+v", steps, want)
+	}
+
+	_, err = convertSteps([]Step{{Includes: []string{"steps/cycle.yaml"}}}, cfgFile, []string{cfgFile})
+	if err == nil || !strings.Contains(err.Error(), "included circularly") {
+		t.Errorf("convertSteps() error = %v, want include cycle error", err)
+	}
+}
@@ -0,0 +1,101 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// ErrMatrixNotSelected is set to GlobalE2EConfig.Error when the config has a matrix but no combination is selected,
+// the config cannot be finalized because it depends on the variables of the combination.
+var ErrMatrixNotSelected = errors.New("the e2e config has a matrix, run all the combinations by `e2e run`, " +
+	"or select one of them by --matrix-index")
+
+// MatrixCombination is a combination of the matrix values, keyed by the matrix keys.
+type MatrixCombination map[string]string
+
+// String returns the combination sorted by the keys, such as `jdk=17, storage=elasticsearch`.
+func (c MatrixCombination) String() string {
+	pairs := make([]string, 0, len(c))
+	for _, key := range sortedKeys(c) {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, c[key]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// MatrixCombinations returns all the combinations of the matrix, the keys are sorted
+// and the values of the last key change first.
+func (c *E2EConfig) MatrixCombinations() []MatrixCombination {
+	if len(c.Matrix) == 0 {
+		return nil
+	}
+
+	combinations := []MatrixCombination{{}}
+	for _, key := range sortedKeys(c.Matrix) {
+		expanded := make([]MatrixCombination, 0, len(combinations)*len(c.Matrix[key]))
+		for _, combination := range combinations {
+			for _, value := range c.Matrix[key] {
+				next := MatrixCombination{key: value}
+				for k, v := range combination {
+					next[k] = v
+				}
+				expanded = append(expanded, next)
+			}
+		}
+		combinations = expanded
+	}
+	return combinations
+}
+
+// selectMatrixCombination selects the combination of util.MatrixIndex,
+// and exports its values as environment variables for the following steps.
+func selectMatrixCombination(conf *E2EConfig) (MatrixCombination, error) {
+	combinations := conf.MatrixCombinations()
+	if len(combinations) == 0 {
+		if util.MatrixIndex >= 0 {
+			return nil, fmt.Errorf("--matrix-index is set but the e2e config has no matrix")
+		}
+		return nil, nil
+	}
+	if util.MatrixIndex < 0 {
+		return nil, ErrMatrixNotSelected
+	}
+	if util.MatrixIndex >= len(combinations) {
+		return nil, fmt.Errorf("--matrix-index %d is out of range, the matrix only has %d combination(s)", util.MatrixIndex, len(combinations))
+	}
+
+	combination := combinations[util.MatrixIndex]
+	util.Env.SetAll(combination)
+	logger.Log.Infof("selected matrix combination %d: %s", util.MatrixIndex, combination)
+	return combination, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
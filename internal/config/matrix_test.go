@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func TestE2EConfig_MatrixCombinations(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix map[string][]string
+		want   []MatrixCombination
+	}{
+		{
+			name:   "No matrix",
+			matrix: nil,
+			want:   nil,
+		},
+		{
+			name:   "Sorted keys and the last key changes first",
+			matrix: map[string][]string{"storage": {"h2", "es"}, "jdk": {"11", "17"}},
+			want: []MatrixCombination{
+				{"jdk": "11", "storage": "h2"},
+				{"jdk": "11", "storage": "es"},
+				{"jdk": "17", "storage": "h2"},
+				{"jdk": "17", "storage": "es"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &E2EConfig{Matrix: tt.matrix}
+			if got := conf.MatrixCombinations(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatrixCombinations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectMatrixCombination(t *testing.T) {
+	defer func() {
+		util.MatrixIndex = -1
+	}()
+	conf := &E2EConfig{Matrix: map[string][]string{"E2E_TEST_STORAGE": {"h2", "es"}}}
+
+	util.MatrixIndex = -1
+	if _, err := selectMatrixCombination(conf); !errors.Is(err, ErrMatrixNotSelected) {
+		t.Errorf("selectMatrixCombination() error = %v, want %v", err, ErrMatrixNotSelected)
+	}
+
+	util.MatrixIndex = 2
+	if _, err := selectMatrixCombination(conf); err == nil {
+		t.Errorf("selectMatrixCombination() should fail when the index is out of range")
+	}
+
+	util.MatrixIndex = 1
+	combination, err := selectMatrixCombination(conf)
+	if err != nil {
+		t.Fatalf("selectMatrixCombination() error = %v", err)
+	}
+	if combination.String() != "E2E_TEST_STORAGE=es" {
+		t.Errorf("selectMatrixCombination() = %v, want E2E_TEST_STORAGE=es", combination)
+	}
+	if got := util.Env.Get("E2E_TEST_STORAGE"); got != "es" {
+		t.Errorf("E2E_TEST_STORAGE = %v, want es", got)
+	}
+}
@@ -0,0 +1,212 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// OverrideEnvPrefix is the prefix of the environment variables overriding the config,
+// such as `E2E_SET_VERIFY__RETRY__COUNT=3` for `--set verify.retry.count=3`.
+const OverrideEnvPrefix = "E2E_SET_"
+
+var overrideSegmentRegex = regexp.MustCompile(`^([^\[\]]+)((?:\[\d+\])*)$`)
+
+// override is a value set to the key path of the config, such as `verify.retry.count=3`.
+type override struct {
+	source string
+	path   string
+	value  string
+}
+
+// collectOverrides collects the overrides from the `E2E_SET_*` environment variables and then the `--set` flags,
+// so that the flags take precedence.
+func collectOverrides() ([]override, error) {
+	var overrides []override
+
+	var envs []string
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, OverrideEnvPrefix) {
+			envs = append(envs, env)
+		}
+	}
+	sort.Strings(envs)
+	for _, env := range envs {
+		name, value, _ := strings.Cut(env, "=")
+		overrides = append(overrides, override{source: name, path: overridePathOfEnv(name), value: value})
+	}
+
+	for _, set := range util.Overrides {
+		path, value, ok := strings.Cut(set, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid --set %s, should be in the format of path=value", set)
+		}
+		overrides = append(overrides, override{source: "--set " + path, path: strings.TrimSpace(path), value: value})
+	}
+	return overrides, nil
+}
+
+// overridePathOfEnv converts the name of the `E2E_SET_*` environment variable to the key path, the name is lower-cased, and then
+// `___` stands for `_`, `__` for `.` and `_` for `-`, the numeric segments are list indexes, such as
+// `E2E_SET_SETUP__STEPS__0__HELM__SET__IMAGE___TAG` for `setup.steps[0].helm.set.image_tag`.
+func overridePathOfEnv(name string) string {
+	name = strings.ToLower(strings.TrimPrefix(name, OverrideEnvPrefix))
+	var segments []string
+	var segment strings.Builder
+	for i := 0; i < len(name); {
+		switch {
+		case strings.HasPrefix(name[i:], "___"):
+			segment.WriteByte('_')
+			i += 3
+		case strings.HasPrefix(name[i:], "__"):
+			segments = append(segments, segment.String())
+			segment.Reset()
+			i += 2
+		case name[i] == '_':
+			segment.WriteByte('-')
+			i++
+		default:
+			segment.WriteByte(name[i])
+			i++
+		}
+	}
+	segments = append(segments, segment.String())
+
+	path := segments[0]
+	for _, s := range segments[1:] {
+		if _, err := strconv.Atoi(s); err == nil {
+			path += "[" + s + "]"
+		} else {
+			path += "." + s
+		}
+	}
+	return path
+}
+
+// applyOverrides sets the values of the `E2E_SET_*` environment variables and the `--set` flags to the config.
+func applyOverrides(conf *E2EConfig) error {
+	overrides, err := collectOverrides()
+	if err != nil {
+		return err
+	}
+	for _, o := range overrides {
+		if err := setConfigValue(reflect.ValueOf(conf).Elem(), o.path, o.value); err != nil {
+			return fmt.Errorf("failed to override the config by %s: %v", o.source, err)
+		}
+	}
+	return nil
+}
+
+// setConfigValue sets the value to the yaml key path of the config, such as `setup.steps[0].command`,
+// the value is parsed according to the type of the key.
+func setConfigValue(conf reflect.Value, path, value string) error {
+	var keys []string
+	for _, segment := range strings.Split(path, ".") {
+		groups := overrideSegmentRegex.FindStringSubmatch(segment)
+		if groups == nil {
+			return fmt.Errorf("invalid key path %q", path)
+		}
+		keys = append(keys, groups[1])
+		if groups[2] != "" {
+			keys = append(keys, strings.Split(strings.TrimSuffix(groups[2], "]"), "]")...)
+		}
+	}
+	return setValue(conf, keys, value, "")
+}
+
+// setValue sets the value to the keys under v, the list indexes in the keys start with "[".
+func setValue(v reflect.Value, keys []string, value, walked string) error {
+	if len(keys) == 0 {
+		return parseValue(v, value)
+	}
+	key := keys[0]
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), keys, value, walked)
+	case reflect.Struct:
+		for _, f := range schemaFields(v.Type()) {
+			if f.key == key {
+				return setValue(v.FieldByIndex(f.field.Index), keys[1:], value, joinKeyPath(walked, key))
+			}
+		}
+		return fmt.Errorf("unknown key %q", joinKeyPath(walked, key))
+	case reflect.Slice:
+		if !strings.HasPrefix(key, "[") {
+			return fmt.Errorf("%s is a list, should be followed by an index", walked)
+		}
+		idx, _ := strconv.Atoi(key[1:])
+		if idx >= v.Len() {
+			return fmt.Errorf("index %d of %s is out of range, the list has %d item(s)", idx, walked, v.Len())
+		}
+		return setValue(v.Index(idx), keys[1:], value, walked+key+"]")
+	case reflect.Map:
+		if strings.HasPrefix(key, "[") {
+			return fmt.Errorf("%s is a map, should be followed by a key", walked)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		// map elements are not addressable, set the copy back after changing it
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(reflect.ValueOf(key)); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setValue(elem, keys[1:], value, joinKeyPath(walked, key)); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key), elem)
+		return nil
+	default:
+		return fmt.Errorf("%s has no key %q", walked, strings.TrimPrefix(key, "["))
+	}
+}
+
+// parseValue parses the value as YAML into the type of v, strings are kept as they are.
+func parseValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(value)
+		return nil
+	}
+	parsed := reflect.New(v.Type())
+	if err := yaml.Unmarshal([]byte(value), parsed.Interface()); err != nil {
+		return fmt.Errorf("invalid value %q: %v", value, err)
+	}
+	v.Set(parsed.Elem())
+	return nil
+}
+
+func joinKeyPath(walked, key string) string {
+	if walked == "" {
+		return key
+	}
+	return walked + "." + key
+}
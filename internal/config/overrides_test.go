@@ -0,0 +1,127 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	base := func() E2EConfig {
+		return E2EConfig{
+			Setup:  Setup{Steps: []Step{{Name: "install", Command: "make install"}}},
+			Verify: Verify{FailFast: true, RetryStrategy: VerifyRetryStrategy{Count: 10}},
+		}
+	}
+	tests := []struct {
+		name      string
+		overrides []string
+		envs      map[string]string
+		want      func(*E2EConfig)
+		wantErr   string
+	}{
+		{
+			name:      "Scalars are parsed by the type of the key",
+			overrides: []string{"verify.retry.count=3", "verify.fail-fast=false", "cleanup.on=never", "setup.timeout=30m"},
+			want: func(c *E2EConfig) {
+				c.Verify.RetryStrategy.Count = 3
+				c.Verify.FailFast = false
+				c.Cleanup.On = "never"
+				c.Setup.Timeout = "30m"
+			},
+		},
+		{
+			name:      "List items and map keys",
+			overrides: []string{"setup.steps[0].command=make test", "trigger.headers.Content-Type=application/json", "matrix.jdk=[11, 17]"},
+			want: func(c *E2EConfig) {
+				c.Setup.Steps[0].Command = "make test"
+				c.Trigger.Headers = map[string]string{"Content-Type": "application/json"}
+				c.Matrix = map[string][]string{"jdk": {"11", "17"}}
+			},
+		},
+		{
+			name:      "Flags take precedence over environment variables",
+			overrides: []string{"verify.retry.count=5"},
+			envs:      map[string]string{"E2E_SET_VERIFY__RETRY__COUNT": "3", "E2E_SET_VERIFY__FAIL_FAST": "false"},
+			want: func(c *E2EConfig) {
+				c.Verify.RetryStrategy.Count = 5
+				c.Verify.FailFast = false
+			},
+		},
+		{
+			name: "Environment variables with list indexes and escaped underscores",
+			envs: map[string]string{"E2E_SET_SETUP__STEPS__0__HELM__SET__IMAGE___TAG": "10.0.0", "E2E_SET_SETUP__STEPS__0__NAME": "install oap"},
+			want: func(c *E2EConfig) {
+				c.Setup.Steps[0].Name = "install oap"
+				c.Setup.Steps[0].Helm = &HelmStep{Set: map[string]string{"image_tag": "10.0.0"}}
+			},
+		},
+		{
+			name:      "Unknown key",
+			overrides: []string{"verify.retry.times=3"},
+			wantErr:   `unknown key "verify.retry.times"`,
+		},
+		{
+			name:      "Invalid value",
+			overrides: []string{"verify.retry.count=many"},
+			wantErr:   `invalid value "many"`,
+		},
+		{
+			name:      "Index out of range",
+			overrides: []string{"setup.steps[1].command=make test"},
+			wantErr:   "index 1 of setup.steps is out of range",
+		},
+		{
+			name:      "Missing value",
+			overrides: []string{"verify.retry.count"},
+			wantErr:   "should be in the format of path=value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			util.Overrides = tt.overrides
+			defer func() {
+				util.Overrides = nil
+			}()
+			for name, value := range tt.envs {
+				t.Setenv(name, value)
+			}
+
+			got := base()
+			err := applyOverrides(&got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyOverrides() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyOverrides() error = %v", err)
+			}
+			want := base()
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("applyOverrides() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+const (
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+	// durationPattern matches the strings accepted by time.ParseDuration.
+	durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+	// portPattern matches the strings accepted by ParseContainerPort.
+	portPattern = `^[0-9]+(/(tcp|udp))?$`
+)
+
+// Schema is the subset of JSON Schema (draft-07) used to describe the e2e config files.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+
+	// closed is marshaled as "additionalProperties: false", which cannot be expressed by *Schema.
+	closed bool
+}
+
+// MarshalJSON adds "additionalProperties: false" to the closed objects.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if !s.closed {
+		return json.Marshal((*plain)(s))
+	}
+	return json.Marshal(&struct {
+		*plain
+		AdditionalProperties bool `json:"additionalProperties"`
+	}{plain: (*plain)(s)})
+}
+
+// The rules below cannot be reflected from the structs, they are keyed by "<struct name>.<yaml key>"
+// for fields and by "<struct name>" for objects, and kept in line with the checks in validate.go.
+var (
+	schemaEnums = map[string][]string{
+		"Setup.env":        supportedEnvs,
+		"Cleanup.on":       supportedCleanupOns,
+		"CollectConfig.on": supportedCollectOns,
+		"Trigger.action":   supportedActions,
+		"Step.mode":        supportedModes,
+		"Step.render":      supportedRenders,
+	}
+	// schemaDurations are the fields parsed by time.ParseDuration, the ones of `any` type also accept
+	// a number of seconds for compatibility, see parseInterval.
+	schemaDurations = []string{
+		"Setup.timeout", "Trigger.interval", "VerifyRetryStrategy.interval", "Step.timeout", "StepRetry.interval", "Wait.interval",
+	}
+	// schemaPorts are the fields of the container ports, which are the port numbers or `<port>/<protocol>`.
+	schemaPorts = []string{"Container.ports"}
+	// schemaRequired are the keys that must be present in an object.
+	schemaRequired = map[string][]string{
+		"KindExposePort": {"resource", "port"},
+		"Container":      {"name", "image"},
+		"HelmStep":       {"chart", "release"},
+		"LogWait":        {"pattern"},
+	}
+	// schemaOneOf are the keys of which exactly one must be present in an object.
+	schemaOneOf = map[string][]string{
+		"Step":    {"path", "kustomize", "command", "helm", "includes"},
+		"Wait":    {"resource", "tcp", "http", "command", "log"},
+		"LogWait": {"service", "resource"},
+	}
+	// schemaExclusive are the pairs of keys that cannot be present in an object at the same time.
+	schemaExclusive = map[string][][2]string{
+		"Setup":      {{"file", "kubeconfig"}},
+		"VerifyCase": {{"includes", "query"}, {"includes", "expected"}, {"actual", "query"}},
+	}
+)
+
+// SchemaTarget is the kind of file to generate the schema for.
+type SchemaTarget int
+
+const (
+	SchemaConfig SchemaTarget = iota
+	SchemaReusingCases
+	SchemaReusingSteps
+)
+
+var schemaTargets = map[SchemaTarget]struct {
+	typ   reflect.Type
+	title string
+}{
+	SchemaConfig:       {typ: reflect.TypeOf(E2EConfig{}), title: "SkyWalking Infra E2E config file"},
+	SchemaReusingCases: {typ: reflect.TypeOf(ReusingCases{}), title: "SkyWalking Infra E2E reusing cases file"},
+	SchemaReusingSteps: {typ: reflect.TypeOf(ReusingSteps{}), title: "SkyWalking Infra E2E reusing steps file"},
+}
+
+// GenerateSchema generates the JSON Schema of the e2e config file, or of the files it includes.
+func GenerateSchema(target SchemaTarget) *Schema {
+	schema := schemaOf(schemaTargets[target].typ, "")
+	schema.Title = schemaTargets[target].title
+	schema.Schema = jsonSchemaDraft
+	return schema
+}
+
+// schemaOf reflects the schema of the type, field is the "<struct name>.<yaml key>" the type belongs to.
+func schemaOf(t reflect.Type, field string) *Schema {
+	if enum, ok := schemaEnums[field]; ok {
+		return &Schema{Type: "string", Enum: enum}
+	}
+	if contains(schemaDurations, field) {
+		duration := &Schema{Type: "string", Pattern: durationPattern}
+		if t.Kind() != reflect.Interface {
+			return duration
+		}
+		zero := 0
+		return &Schema{AnyOf: []*Schema{duration, {Type: "integer", Minimum: &zero}}}
+	}
+
+	if contains(schemaPorts, field) && t.Kind() == reflect.String {
+		one := 1
+		return &Schema{AnyOf: []*Schema{{Type: "integer", Minimum: &one}, {Type: "string", Pattern: portPattern}}}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), field)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), field)}
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), field)
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		// any, the value is checked when loading the config
+		return &Schema{}
+	}
+}
+
+func structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}, closed: true}
+	for _, f := range schemaFields(t) {
+		schema.Properties[f.key] = schemaOf(f.field.Type, t.Name()+"."+f.key)
+	}
+
+	name := t.Name()
+	schema.Required = schemaRequired[name]
+	if keys, ok := schemaOneOf[name]; ok {
+		for _, key := range keys {
+			schema.OneOf = append(schema.OneOf, &Schema{Required: []string{key}})
+		}
+	}
+	for _, pair := range schemaExclusive[name] {
+		schema.AllOf = append(schema.AllOf, &Schema{Not: &Schema{Required: []string{pair[0], pair[1]}}})
+	}
+	return schema
+}
+
+type schemaField struct {
+	key   string
+	field reflect.StructField
+}
+
+// schemaFields lists the fields of the struct that are read from the yaml file, by their yaml keys.
+func schemaFields(t reflect.Type) []schemaField {
+	var fields []schemaField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(f.Name)
+		}
+		fields = append(fields, schemaField{key: key, field: f})
+	}
+	return fields
+}
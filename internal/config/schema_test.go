@@ -0,0 +1,161 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestGenerateSchema makes sure every yaml key of the config structs is described in the schema,
+// and objects reject unknown keys like the validate command does.
+func TestGenerateSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		target SchemaTarget
+		typ    reflect.Type
+	}{
+		{name: "e2e config", target: SchemaConfig, typ: reflect.TypeOf(E2EConfig{})},
+		{name: "reusing cases", target: SchemaReusingCases, typ: reflect.TypeOf(ReusingCases{})},
+		{name: "reusing steps", target: SchemaReusingSteps, typ: reflect.TypeOf(ReusingSteps{})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := GenerateSchema(tt.target)
+			if _, err := json.Marshal(schema); err != nil {
+				t.Fatalf("failed to marshal the schema: %v", err)
+			}
+			assertSchemaCovers(t, tt.typ, schema, "")
+		})
+	}
+}
+
+func assertSchemaCovers(t *testing.T, typ reflect.Type, schema *Schema, path string) {
+	t.Helper()
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		if schema.Type != "array" || schema.Items == nil {
+			t.Errorf("%s should be an array in the schema", path)
+			return
+		}
+		assertSchemaCovers(t, typ.Elem(), schema.Items, path+"[]")
+	case reflect.Map:
+		if schema.Type != "object" || schema.AdditionalProperties == nil {
+			t.Errorf("%s should be a map in the schema", path)
+			return
+		}
+		assertSchemaCovers(t, typ.Elem(), schema.AdditionalProperties, path+".*")
+	case reflect.Struct:
+		if schema.Type != "object" || !schema.closed {
+			t.Errorf("%s should be an object without additional properties in the schema", path)
+			return
+		}
+		fields := schemaFields(typ)
+		if len(fields) != len(schema.Properties) {
+			t.Errorf("%s has %d keys, but %d in the schema", path, len(fields), len(schema.Properties))
+		}
+		for _, f := range fields {
+			property, ok := schema.Properties[f.key]
+			if !ok {
+				t.Errorf("%s.%s is missing in the schema", path, f.key)
+				continue
+			}
+			assertSchemaCovers(t, f.field.Type, property, strings.TrimPrefix(path+"."+f.key, "."))
+		}
+	}
+}
+
+// TestSchemaRules makes sure the rules that cannot be reflected still reference existing keys,
+// so renaming a field could not silently drop them from the schema.
+func TestSchemaRules(t *testing.T) {
+	structs := map[string]reflect.Type{}
+	collectStructs(reflect.TypeOf(E2EConfig{}), structs)
+	collectStructs(reflect.TypeOf(ReusingCases{}), structs)
+	collectStructs(reflect.TypeOf(ReusingSteps{}), structs)
+
+	hasKey := func(structName, key string) bool {
+		typ, ok := structs[structName]
+		if !ok {
+			return false
+		}
+		for _, f := range schemaFields(typ) {
+			if f.key == key {
+				return true
+			}
+		}
+		return false
+	}
+	hasField := func(rule string) bool {
+		parts := strings.SplitN(rule, ".", 2)
+		return len(parts) == 2 && hasKey(parts[0], parts[1])
+	}
+
+	for rule, enum := range schemaEnums {
+		if !hasField(rule) {
+			t.Errorf("enum rule %s references a key that does not exist", rule)
+		}
+		if len(enum) == 0 {
+			t.Errorf("enum rule %s has no values", rule)
+		}
+	}
+	for _, rule := range schemaDurations {
+		if !hasField(rule) {
+			t.Errorf("duration rule %s references a key that does not exist", rule)
+		}
+	}
+	for name, keys := range schemaRequired {
+		for _, key := range keys {
+			if !hasKey(name, key) {
+				t.Errorf("required rule %s.%s references a key that does not exist", name, key)
+			}
+		}
+	}
+	for name, keys := range schemaOneOf {
+		for _, key := range keys {
+			if !hasKey(name, key) {
+				t.Errorf("one-of rule %s.%s references a key that does not exist", name, key)
+			}
+		}
+	}
+	for name, pairs := range schemaExclusive {
+		for _, pair := range pairs {
+			for _, key := range pair {
+				if !hasKey(name, key) {
+					t.Errorf("exclusive rule %s.%s references a key that does not exist", name, key)
+				}
+			}
+		}
+	}
+}
+
+func collectStructs(typ reflect.Type, structs map[string]reflect.Type) {
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Ptr:
+		collectStructs(typ.Elem(), structs)
+	case reflect.Struct:
+		if _, ok := structs[typ.Name()]; ok {
+			return
+		}
+		structs[typ.Name()] = typ
+		for _, f := range schemaFields(typ) {
+			collectStructs(f.field.Type, structs)
+		}
+	}
+}
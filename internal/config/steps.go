@@ -0,0 +1,143 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StepDependencies returns the indexes of the steps each step needs, the step starts after all of them succeed.
+// If none of the steps declares `needs`, every step needs the one before it, so they run one after another.
+func StepDependencies(steps []Step) ([][]int, error) {
+	dependencies := make([][]int, len(steps))
+	parallel := false
+	for idx := range steps {
+		if len(steps[idx].Needs) > 0 {
+			parallel = true
+			break
+		}
+	}
+	if !parallel {
+		for idx := 1; idx < len(steps); idx++ {
+			dependencies[idx] = []int{idx - 1}
+		}
+		return dependencies, nil
+	}
+
+	byID := make(map[string]int, len(steps))
+	for idx := range steps {
+		if steps[idx].ID == "" {
+			continue
+		}
+		if _, ok := byID[steps[idx].ID]; ok {
+			return nil, fmt.Errorf("step id %s is declared more than once", steps[idx].ID)
+		}
+		byID[steps[idx].ID] = idx
+	}
+	for idx := range steps {
+		for _, need := range steps[idx].Needs {
+			dependency, ok := byID[need]
+			if !ok {
+				return nil, fmt.Errorf("step %s needs unknown step %s", steps[idx].DisplayName(idx), need)
+			}
+			dependencies[idx] = append(dependencies[idx], dependency)
+		}
+	}
+
+	visited := make([]bool, len(steps))
+	var visit func(idx int, chain []string) error
+	visit = func(idx int, chain []string) error {
+		id := steps[idx].ID
+		if contains(chain, id) {
+			return fmt.Errorf("steps need each other circularly: %s", strings.Join(append(chain, id), " -> "))
+		}
+		if visited[idx] {
+			return nil
+		}
+		for _, dependency := range dependencies[idx] {
+			if err := visit(dependency, append(chain, id)); err != nil {
+				return err
+			}
+		}
+		visited[idx] = true
+		return nil
+	}
+	for idx := range steps {
+		if err := visit(idx, nil); err != nil {
+			return nil, err
+		}
+	}
+	return dependencies, nil
+}
+
+// DisplayName returns the name of the step to show, its id, name or index in order.
+func (s *Step) DisplayName(idx int) string {
+	switch {
+	case s.ID != "":
+		return s.ID
+	case s.Name != "":
+		return s.Name
+	default:
+		return fmt.Sprintf("#%d", idx)
+	}
+}
+
+// GetTimeout returns the timeout of every attempt of the step, 0 means it's only limited by the timeout of the setup.
+func (s *Step) GetTimeout() (time.Duration, error) {
+	if s.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(s.Timeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("should be > 0, but was %s", timeout)
+	}
+	return timeout, nil
+}
+
+// Intervals returns the intervals before every retry.
+func (r *StepRetry) Intervals() ([]time.Duration, error) {
+	if r == nil {
+		return nil, nil
+	}
+	var interval time.Duration
+	if r.Interval != "" {
+		var err error
+		if interval, err = time.ParseDuration(r.Interval); err != nil {
+			return nil, err
+		}
+	}
+	backoff := r.Backoff
+	if backoff == 0 {
+		backoff = 1
+	}
+	if backoff < 1 {
+		return nil, fmt.Errorf("backoff should be >= 1, but was %v", r.Backoff)
+	}
+
+	var intervals []time.Duration
+	for i := 0; i < r.Count; i++ {
+		intervals = append(intervals, interval)
+		interval = time.Duration(float64(interval) * backoff)
+	}
+	return intervals, nil
+}
@@ -0,0 +1,138 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStepDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []Step
+		want    [][]int
+		wantErr string
+	}{
+		{
+			name:  "Run one after another without needs",
+			steps: []Step{{Name: "a"}, {ID: "b"}, {Name: "c"}},
+			want:  [][]int{nil, {0}, {1}},
+		},
+		{
+			name: "Run the independent steps concurrently",
+			steps: []Step{
+				{ID: "es"},
+				{ID: "kafka"},
+				{ID: "oap", Needs: []string{"es", "kafka"}},
+				{Name: "ui", Needs: []string{"oap"}},
+			},
+			want: [][]int{nil, nil, {0, 1}, {2}},
+		},
+		{
+			name:    "Unknown step",
+			steps:   []Step{{Name: "oap", Needs: []string{"es"}}},
+			wantErr: "step oap needs unknown step es",
+		},
+		{
+			name: "Circular needs",
+			steps: []Step{
+				{ID: "a", Needs: []string{"b"}},
+				{ID: "b", Needs: []string{"c"}},
+				{ID: "c", Needs: []string{"a"}},
+			},
+			wantErr: "a -> b -> c -> a",
+		},
+		{
+			name:    "Duplicated ids",
+			steps:   []Step{{ID: "a"}, {ID: "a", Needs: []string{"a"}}},
+			wantErr: "step id a is declared more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependencies, err := StepDependencies(tt.steps)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("StepDependencies() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("StepDependencies() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(dependencies, tt.want) {
+				t.Errorf("StepDependencies() = %v, want %v", dependencies, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepRetryIntervals(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   *StepRetry
+		want    []time.Duration
+		wantErr string
+	}{
+		{
+			name: "No retry",
+		},
+		{
+			name:  "Constant interval",
+			retry: &StepRetry{Count: 3, Interval: "5s"},
+			want:  []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:  "Exponential backoff",
+			retry: &StepRetry{Count: 4, Interval: "1s", Backoff: 2},
+			want:  []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name:    "Invalid interval",
+			retry:   &StepRetry{Count: 1, Interval: "soon"},
+			wantErr: "invalid duration",
+		},
+		{
+			name:    "Shrinking backoff",
+			retry:   &StepRetry{Count: 1, Backoff: 0.5},
+			wantErr: "backoff should be >= 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intervals, err := tt.retry.Intervals()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Intervals() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Intervals() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(intervals, tt.want) {
+				t.Errorf("Intervals() = %v, want %v", intervals, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// TemplateData is the data of the Go templates in the config, such as the rendered manifests.
+type TemplateData struct {
+	// Env is the environment variables, including the ones exported by the environment and the steps.
+	Env map[string]string
+	// Matrix is the selected matrix combination, empty if the config has no matrix.
+	Matrix MatrixCombination
+}
+
+// NewTemplateData creates the data with the current environment variables.
+func NewTemplateData() *TemplateData {
+	matrix := GlobalConfig.Matrix
+	if matrix == nil {
+		matrix = MatrixCombination{}
+	}
+	return &TemplateData{Env: util.Env.Environ(), Matrix: matrix}
+}
+
+// ParseCondition parses the condition of a step, which is a Go template rendered to true or false,
+// the `{{ }}` could be omitted if the whole condition is one action, such as `eq .Matrix.storage "elasticsearch"`.
+func ParseCondition(condition string) (*template.Template, error) {
+	if !strings.Contains(condition, "{{") {
+		condition = "{{ " + condition + " }}"
+	}
+	// the missing variables are empty, so it's easy to check whether a variable is set
+	return template.New("if").Option("missingkey=zero").Parse(condition)
+}
+
+// EvaluateCondition renders the condition with the data and parses the result as a bool.
+func EvaluateCondition(condition string, data *TemplateData) (bool, error) {
+	tmpl, err := ParseCondition(condition)
+	if err != nil {
+		return false, err
+	}
+	var result bytes.Buffer
+	if err := tmpl.Execute(&result, data); err != nil {
+		return false, err
+	}
+	value, err := strconv.ParseBool(strings.TrimSpace(result.String()))
+	if err != nil {
+		return false, fmt.Errorf("condition %q should be rendered to true or false, but got %q", condition, result.String())
+	}
+	return value, nil
+}
@@ -0,0 +1,80 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	data := &TemplateData{
+		Env:    map[string]string{"OAP_TAG": "latest"},
+		Matrix: MatrixCombination{"storage": "elasticsearch"},
+	}
+	tests := []struct {
+		name      string
+		condition string
+		want      bool
+		wantErr   string
+	}{
+		{
+			name:      "Condition without braces",
+			condition: `eq .Matrix.storage "elasticsearch"`,
+			want:      true,
+		},
+		{
+			name:      "Condition with braces",
+			condition: `{{ if eq .Env.OAP_TAG "latest" }}false{{ else }}true{{ end }}`,
+			want:      false,
+		},
+		{
+			name:      "Missing variable is empty",
+			condition: `ne .Env.NOT_SET ""`,
+			want:      false,
+		},
+		{
+			name:      "Not a bool",
+			condition: `.Env.OAP_TAG`,
+			wantErr:   `should be rendered to true or false, but got "latest"`,
+		},
+		{
+			name:      "Invalid template",
+			condition: `{{ eq .Env.OAP_TAG`,
+			wantErr:   "unclosed action",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateCondition(tt.condition, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("EvaluateCondition() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvaluateCondition() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
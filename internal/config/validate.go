@@ -0,0 +1,768 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+
+	"github.com/apache/skywalking-infra-e2e/internal/components/verifier"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+var (
+	supportedEnvs       = []string{constant.Kind, constant.Compose, constant.Local, constant.Containers}
+	supportedCleanupOns = []string{constant.CleanUpAlways, constant.CleanUpOnSuccess, constant.CleanUpOnFailure, constant.CleanUpNever}
+	supportedCollectOns = []string{constant.CollectAlways, constant.CollectOnFailure, constant.CollectNever}
+	supportedActions    = []string{constant.ActionHTTP}
+	supportedModes      = []string{constant.ManifestCreate, constant.ManifestApply, constant.ManifestReplace}
+	supportedRenders    = []string{constant.RenderEnv, constant.RenderTemplate}
+
+	yamlErrorLineRegex     = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	yamlUnknownFieldRegex  = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+	templateErrorLineRegex = regexp.MustCompile(`^template: .*:(\d+): (.*)$`)
+	listIndexRegex         = regexp.MustCompile(`\[(\d+)\]`)
+	matrixKeyRegex         = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	containerNameRegex     = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	stepIDRegex            = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+)
+
+// RegisterEnv adds the env to the supported values of `setup.env`, for the environments registered by others.
+func RegisterEnv(env string) {
+	if !contains(supportedEnvs, env) {
+		supportedEnvs = append(supportedEnvs, env)
+		schemaEnums["Setup.env"] = supportedEnvs
+	}
+}
+
+// ValidationError is a single problem found in a config file.
+type ValidationError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Msg)
+}
+
+// ValidationErrors holds all the problems found in a config file and the files it includes.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("found %d problem(s) in the e2e config:\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// Validate strictly decodes the e2e config file and every file it includes,
+// and reports unknown keys, invalid values and missing files with their file and line.
+func Validate(cfgFile string) error {
+	absFile, err := filepath.Abs(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	v := &validator{visited: make(map[string]bool)}
+	v.validateConfigFile(absFile)
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+type validator struct {
+	errs    ValidationErrors
+	visited map[string]bool
+	// including are the files including the steps being checked, to detect the include cycles.
+	including []string
+}
+
+// source is a decoded config file, along with the file and line each key path is declared at,
+// the keys of a config file extending others could be declared in any file of the chain.
+type source struct {
+	file  string
+	lines lineIndex
+}
+
+func (v *validator) reportf(src *source, path, format string, args ...any) {
+	e := &ValidationError{File: src.file, Msg: fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...))}
+	if pos, ok := src.lines.position(path); ok {
+		e.File, e.Line = pos.file, pos.line
+	}
+	v.errs = append(v.errs, e)
+}
+
+// decodeStrict decodes the data into out, reporting unknown keys and mismatched types,
+// it returns false when the file is not a valid YAML file at all.
+func (v *validator) decodeStrict(file string, data []byte, out any) bool {
+	err := yaml.UnmarshalStrict(data, out)
+	if err == nil {
+		return true
+	}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		for _, msg := range typeErr.Errors {
+			v.reportDecodeError(file, msg)
+		}
+		return true
+	}
+	v.reportDecodeError(file, err.Error())
+	return false
+}
+
+func (v *validator) reportDecodeError(file, msg string) {
+	e := &ValidationError{File: file, Msg: msg}
+	if groups := yamlErrorLineRegex.FindStringSubmatch(msg); groups != nil {
+		e.Line, _ = strconv.Atoi(groups[1])
+		e.Msg = groups[2]
+	}
+	if groups := yamlUnknownFieldRegex.FindStringSubmatch(e.Msg); groups != nil {
+		e.Msg = fmt.Sprintf("unknown key %q", groups[1])
+	}
+	v.errs = append(v.errs, e)
+}
+
+func (v *validator) readFile(file string) ([]byte, bool) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		v.errs = append(v.errs, &ValidationError{File: file, Msg: err.Error()})
+		return nil, false
+	}
+	return data, true
+}
+
+func (v *validator) validateConfigFile(file string) {
+	// decode every file of the extends chain strictly, and check the merged config
+	var chain []*configLayer
+	var indexes []lineIndex
+	extended := make(map[string]bool)
+	for file != "" {
+		extended[file] = true
+		data, ok := v.readFile(file)
+		if !ok {
+			return
+		}
+		var conf E2EConfig
+		if !v.decodeStrict(file, data, &conf) {
+			return
+		}
+		src := &source{file: file, lines: buildLineIndex(file, data)}
+
+		layer := &configLayer{file: file, data: data, extends: resolveExtends(conf.Extends, file)}
+		if extended[layer.extends] {
+			v.reportf(src, "extends", "config file %s is extended circularly", layer.extends)
+			return
+		}
+		if layer.extends != "" && !util.PathExist(layer.extends) {
+			v.reportf(src, "extends", "extended config file %s does not exist", layer.extends)
+			return
+		}
+		chain = append([]*configLayer{layer}, chain...)
+		indexes = append([]lineIndex{src.lines}, indexes...)
+		file = layer.extends
+	}
+	lines := indexes[0]
+	for _, idx := range indexes[1:] {
+		lines = idx.extend(lines)
+	}
+
+	data, err := mergeConfigChain(chain)
+	if err != nil {
+		v.errs = append(v.errs, &ValidationError{File: chain[len(chain)-1].file, Msg: err.Error()})
+		return
+	}
+	var conf E2EConfig
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		v.errs = append(v.errs, &ValidationError{File: chain[len(chain)-1].file, Msg: err.Error()})
+		return
+	}
+	src := &source{file: chain[len(chain)-1].file, lines: lines}
+	v.including = []string{src.file}
+	if err := applyOverrides(&conf); err != nil {
+		v.errs = append(v.errs, &ValidationError{File: src.file, Msg: err.Error()})
+		return
+	}
+
+	v.checkMatrix(src, conf.Matrix)
+	v.checkSetup(src, &conf.Setup)
+	v.checkCleanup(src, &conf.Cleanup)
+	v.checkTrigger(src, &conf.Trigger)
+	v.checkVerify(src, &conf.Verify)
+}
+
+func (v *validator) validateStepsFile(file string) {
+	if v.visited[file] {
+		return
+	}
+	v.visited[file] = true
+
+	data, ok := v.readFile(file)
+	if !ok {
+		return
+	}
+
+	var steps ReusingSteps
+	if !v.decodeStrict(file, data, &steps) {
+		return
+	}
+	src := &source{file: file, lines: buildLineIndex(file, data)}
+
+	v.including = append(v.including, file)
+	defer func() {
+		v.including = v.including[:len(v.including)-1]
+	}()
+	for idx := range steps.Steps {
+		v.checkStep(src, fmt.Sprintf("steps[%d]", idx), &steps.Steps[idx])
+	}
+}
+
+func (v *validator) validateCasesFile(file string) {
+	if v.visited[file] {
+		return
+	}
+	v.visited[file] = true
+
+	data, ok := v.readFile(file)
+	if !ok {
+		return
+	}
+
+	var cases ReusingCases
+	if !v.decodeStrict(file, data, &cases) {
+		return
+	}
+	src := &source{file: file, lines: buildLineIndex(file, data)}
+
+	for idx := range cases.Cases {
+		v.checkCase(src, fmt.Sprintf("cases[%d]", idx), &cases.Cases[idx])
+	}
+}
+
+func (v *validator) checkMatrix(src *source, matrix map[string][]string) {
+	for _, key := range sortedKeys(matrix) {
+		path := "matrix." + key
+		if !matrixKeyRegex.MatchString(key) {
+			v.reportf(src, path, "should be a valid environment variable name")
+		}
+		if len(matrix[key]) == 0 {
+			v.reportf(src, path, "should have at least one value")
+		}
+	}
+}
+
+func (v *validator) checkSetup(src *source, setup *Setup) {
+	if setup.Env != "" && !contains(supportedEnvs, setup.Env) {
+		v.reportf(src, "setup.env", "unsupported env %q, should be one of %s", setup.Env, strings.Join(supportedEnvs, ", "))
+	}
+	switch setup.Env {
+	case constant.Kind:
+		if setup.File != "" && setup.Kubeconfig != "" {
+			v.reportf(src, "setup.kubeconfig", "file and kubeconfig cannot be provided at the same time")
+		} else if setup.File == "" && setup.Kubeconfig == "" {
+			v.reportf(src, "setup", "one of file or kubeconfig should be provided for kind env")
+		}
+	case constant.Compose:
+		if setup.File == "" {
+			v.reportf(src, "setup", "file should be provided for compose env")
+		}
+		for idx, file := range setup.Compose.Files {
+			if file == "" {
+				v.reportf(src, fmt.Sprintf("setup.compose.files[%d]", idx), "file should be specified")
+			}
+		}
+		for idx, profile := range setup.Compose.Profiles {
+			if profile == "" {
+				v.reportf(src, fmt.Sprintf("setup.compose.profiles[%d]", idx), "profile should be specified")
+			}
+		}
+		for idx, service := range setup.Compose.OneShot {
+			if service == "" {
+				v.reportf(src, fmt.Sprintf("setup.compose.one-shot[%d]", idx), "service should be specified")
+			}
+		}
+	case constant.Local:
+		if setup.File != "" || setup.Kubeconfig != "" {
+			v.reportf(src, "setup", "file and kubeconfig are not supported by local env")
+		}
+	case constant.Containers:
+		if setup.File != "" || setup.Kubeconfig != "" {
+			v.reportf(src, "setup", "file and kubeconfig are not supported by containers env")
+		}
+		v.checkContainers(src, setup.Containers)
+	}
+
+	compose := &setup.Compose
+	if setup.Env != constant.Compose && (len(compose.Files) > 0 || len(compose.Profiles) > 0 || len(compose.OneShot) > 0) {
+		v.reportf(src, "setup.compose", "compose is only supported by compose env")
+	}
+
+	if _, err := parseInterval(setup.Timeout, "setup.timeout"); err != nil {
+		v.reportf(src, "setup.timeout", "%v", err)
+	}
+
+	for idx := range setup.Steps {
+		path := fmt.Sprintf("setup.steps[%d]", idx)
+		step := &setup.Steps[idx]
+		v.checkStep(src, path, step)
+		if step.Background && setup.Env != constant.Local {
+			v.reportf(src, path+".background", "background steps are only supported by local env")
+		}
+		if step.Path != "" && (setup.Env == constant.Local || setup.Env == constant.Containers) {
+			v.reportf(src, path+".path", "manifests are not supported by %s env", setup.Env)
+		}
+		if step.Kustomize != "" && setup.Env != "" && setup.Env != constant.Kind {
+			v.reportf(src, path+".kustomize", "kustomize steps are only supported by kind env")
+		}
+		if step.Helm != nil && setup.Env != "" && setup.Env != constant.Kind {
+			v.reportf(src, path+".helm", "helm steps are only supported by kind env")
+		}
+	}
+
+	// the steps of the included files are checked after they are included when loading the config
+	included := false
+	for idx := range setup.Steps {
+		included = included || len(setup.Steps[idx].Includes) > 0
+	}
+	if !included {
+		if _, err := StepDependencies(setup.Steps); err != nil {
+			v.reportf(src, "setup.steps", "%v", err)
+		}
+	}
+
+	for idx := range setup.Waits {
+		path := fmt.Sprintf("setup.wait[%d]", idx)
+		wait := &setup.Waits[idx]
+		v.checkWait(src, path, wait)
+		if setup.Env == "" || setup.Env == constant.Kind {
+			continue
+		}
+		if wait.Resource != "" {
+			v.reportf(src, path+".resource", "resource waits are only supported by kind env")
+		}
+		if wait.Log != nil && wait.Log.Resource != "" {
+			v.reportf(src, path+".log.resource", "resource logs are only supported by kind env")
+		}
+	}
+
+	for idx, port := range setup.Kind.ExposePorts {
+		path := fmt.Sprintf("setup.kind.expose-ports[%d]", idx)
+		if port.Resource == "" {
+			v.reportf(src, path, "resource should be specified")
+		}
+		if port.Port == "" {
+			v.reportf(src, path, "port should be specified")
+		}
+	}
+}
+
+func (v *validator) checkContainers(src *source, containers []Container) {
+	if len(containers) == 0 {
+		v.reportf(src, "setup", "at least one container should be declared for containers env")
+		return
+	}
+	for idx := range containers {
+		path := fmt.Sprintf("setup.containers[%d]", idx)
+		container := &containers[idx]
+		if !containerNameRegex.MatchString(container.Name) {
+			v.reportf(src, path+".name", "invalid container name %q, should match %s", container.Name, containerNameRegex)
+		}
+		if container.Image == "" {
+			v.reportf(src, path, "image should be specified")
+		}
+		waits := 0
+		for _, key := range []string{container.Wait.HTTP, container.Wait.Log, container.Wait.Command} {
+			if key != "" {
+				waits++
+			}
+		}
+		if waits > 1 {
+			v.reportf(src, path+".wait", "only one of http, log or command could be specified")
+		}
+		tcpPorts := 0
+		for portIdx, port := range container.Ports {
+			_, protocol, err := ParseContainerPort(port)
+			if err != nil {
+				v.reportf(src, fmt.Sprintf("%s.ports[%d]", path, portIdx), "%v", err)
+			} else if protocol == "tcp" {
+				tcpPorts++
+			}
+		}
+		if container.Wait.HTTP != "" && container.Wait.Port == 0 && tcpPorts == 0 {
+			v.reportf(src, path+".wait.http", "port should be specified to wait for http")
+		}
+		if container.Wait.Log != "" {
+			if _, err := regexp.Compile(container.Wait.Log); err != nil {
+				v.reportf(src, path+".wait.log", "invalid regex: %v", err)
+			}
+		}
+	}
+	if _, err := SortContainers(containers); err != nil {
+		v.reportf(src, "setup.containers", "%v", err)
+	}
+}
+
+func (v *validator) checkStep(src *source, path string, step *Step) {
+	if len(step.Includes) > 0 {
+		if step.Path != "" || step.Kustomize != "" || step.Command != "" || step.Helm != nil || len(step.Waits) > 0 {
+			v.reportf(src, path, "includes and path/kustomize/command/helm/wait only support selecting one of them in a step")
+		}
+		if step.ID != "" || len(step.Needs) > 0 || step.Timeout != "" || step.Retry != nil || step.If != "" || step.ContinueOnError {
+			v.reportf(src, path, "id, needs, timeout, retry, if and continue-on-error are not supported by includes, set them on the included steps")
+		}
+		for idx, include := range step.Includes {
+			includePath := util.ResolveAbsWithBase(include, src.file)
+			includeKey := fmt.Sprintf("%s.includes[%d]", path, idx)
+			if contains(v.including, includePath) {
+				v.reportf(src, includeKey, "reuse step config file %s is included circularly", includePath)
+				continue
+			}
+			if !util.PathExist(includePath) {
+				v.reportf(src, includeKey, "reuse step config file %s does not exist", includePath)
+				continue
+			}
+			v.validateStepsFile(includePath)
+		}
+		return
+	}
+
+	if step.ID != "" && !stepIDRegex.MatchString(step.ID) {
+		v.reportf(src, path+".id", "invalid step id %q, should match %s", step.ID, stepIDRegex)
+	}
+
+	kinds := 0
+	for _, specified := range []bool{step.Path != "", step.Kustomize != "", step.Command != "", step.Helm != nil} {
+		if specified {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		v.reportf(src, path, "one of path, kustomize, command or helm should be specified")
+	}
+	if step.Mode != "" {
+		if step.Path == "" && step.Kustomize == "" {
+			v.reportf(src, path+".mode", "mode is only supported by path and kustomize steps")
+		} else if !contains(supportedModes, step.Mode) {
+			v.reportf(src, path+".mode", "unsupported mode %q, should be one of %s", step.Mode, strings.Join(supportedModes, ", "))
+		}
+	}
+	if step.Render != "" {
+		if step.Path == "" && step.Kustomize == "" {
+			v.reportf(src, path+".render", "render is only supported by path and kustomize steps")
+		} else if !contains(supportedRenders, step.Render) {
+			v.reportf(src, path+".render", "unsupported render %q, should be one of %s", step.Render, strings.Join(supportedRenders, ", "))
+		}
+	}
+	if step.Helm != nil {
+		if step.Helm.Chart == "" {
+			v.reportf(src, path+".helm", "chart should be specified")
+		}
+		if step.Helm.Release == "" {
+			v.reportf(src, path+".helm", "release should be specified")
+		}
+	}
+	if step.Background && step.Command == "" {
+		v.reportf(src, path+".background", "only command steps could run in the background")
+	}
+	if _, err := step.GetTimeout(); err != nil {
+		v.reportf(src, path+".timeout", "%v", err)
+	}
+	if step.Retry != nil {
+		if step.Retry.Count < 0 {
+			v.reportf(src, path+".retry.count", "should be >= 0, but was %d", step.Retry.Count)
+		}
+		if _, err := step.Retry.Intervals(); err != nil {
+			v.reportf(src, path+".retry", "%v", err)
+		}
+		if step.Background {
+			v.reportf(src, path+".retry", "background steps could not be retried")
+		}
+	}
+	if step.If != "" {
+		if _, err := ParseCondition(step.If); err != nil {
+			v.reportf(src, path+".if", "%v", err)
+		}
+	}
+	for waitIdx := range step.Waits {
+		v.checkWait(src, fmt.Sprintf("%s.wait[%d]", path, waitIdx), &step.Waits[waitIdx])
+	}
+}
+
+func (v *validator) checkWait(src *source, path string, wait *Wait) {
+	kinds := 0
+	for _, key := range []string{wait.Resource, wait.TCP, wait.HTTP, wait.Command} {
+		if key != "" {
+			kinds++
+		}
+	}
+	if wait.Log != nil {
+		kinds++
+		v.checkLogWait(src, path+".log", wait.Log)
+	}
+	if kinds != 1 {
+		v.reportf(src, path, "one of resource, tcp, http, command or log should be specified")
+	} else if strings.Contains(wait.Resource, "/") && wait.LabelSelector != "" {
+		v.reportf(src, path+".label-selector", "label-selector cannot be set when resource has a name")
+	}
+	if wait.HTTP == "" && (wait.Method != "" || len(wait.Headers) > 0 || wait.Body != "" ||
+		wait.ExpectedStatus != 0 || wait.ExpectedBody != "") {
+		v.reportf(src, path, "method, headers, body, expected-status and expected-body are only supported by http wait")
+	}
+	if wait.ExpectedStatus != 0 && (wait.ExpectedStatus < 100 || wait.ExpectedStatus > 599) {
+		v.reportf(src, path+".expected-status", "invalid status %d", wait.ExpectedStatus)
+	}
+	if wait.ExpectedBody != "" {
+		if _, err := regexp.Compile(wait.ExpectedBody); err != nil {
+			v.reportf(src, path+".expected-body", "invalid regex: %v", err)
+		}
+	}
+	if wait.Interval != "" {
+		if wait.IsKubernetes() {
+			v.reportf(src, path+".interval", "interval is only supported by tcp, http, command and log waits")
+		} else if _, err := wait.GetInterval(); err != nil {
+			v.reportf(src, path+".interval", "%v", err)
+		}
+	}
+}
+
+func (v *validator) checkLogWait(src *source, path string, wait *LogWait) {
+	if (wait.Service == "") == (wait.Resource == "") {
+		v.reportf(src, path, "one of service or resource should be specified")
+	}
+	if wait.Namespace != "" && wait.Resource == "" {
+		v.reportf(src, path+".namespace", "namespace is only supported by resource")
+	}
+	if wait.Pattern == "" {
+		v.reportf(src, path, "pattern should be specified")
+	} else if _, err := regexp.Compile(wait.Pattern); err != nil {
+		v.reportf(src, path+".pattern", "invalid regex: %v", err)
+	}
+	if wait.Occurrences < 0 {
+		v.reportf(src, path+".occurrences", "should be >= 0, but was %d", wait.Occurrences)
+	}
+}
+
+func (v *validator) checkCleanup(src *source, cleanup *Cleanup) {
+	if cleanup.On != "" && !contains(supportedCleanupOns, cleanup.On) {
+		v.reportf(src, "cleanup.on", "unsupported value %q, should be one of %s", cleanup.On, strings.Join(supportedCleanupOns, ", "))
+	}
+	collect := &cleanup.Collect
+	if collect.On != "" && !contains(supportedCollectOns, collect.On) {
+		v.reportf(src, "cleanup.collect.on", "unsupported value %q, should be one of %s", collect.On, strings.Join(supportedCollectOns, ", "))
+	}
+	if len(collect.Items) > 0 && collect.OutputDir == "" {
+		v.reportf(src, "cleanup.collect", "output-dir is required when items are configured")
+	}
+}
+
+func (v *validator) checkTrigger(src *source, trigger *Trigger) {
+	if trigger.Action == "" {
+		return
+	}
+	if !contains(supportedActions, trigger.Action) {
+		v.reportf(src, "trigger.action", "unsupported action %q, should be one of %s", trigger.Action, strings.Join(supportedActions, ", "))
+		return
+	}
+	if interval, err := time.ParseDuration(trigger.Interval); err != nil {
+		v.reportf(src, "trigger.interval", "%v", err)
+	} else if interval <= 0 {
+		v.reportf(src, "trigger.interval", "should be > 0, but was %s", interval)
+	}
+	if trigger.URL == "" {
+		v.reportf(src, "trigger", "url should be specified for http action")
+	}
+}
+
+func (v *validator) checkVerify(src *source, verify *Verify) {
+	switch interval := verify.RetryStrategy.Interval.(type) {
+	case nil, int:
+	case string:
+		if _, err := time.ParseDuration(interval); err != nil {
+			v.reportf(src, "verify.retry.interval", "%v", err)
+		}
+	default:
+		v.reportf(src, "verify.retry.interval", "unsupported value %v", interval)
+	}
+
+	for idx := range verify.Cases {
+		v.checkCase(src, fmt.Sprintf("verify.cases[%d]", idx), &verify.Cases[idx])
+	}
+}
+
+func (v *validator) checkCase(src *source, path string, verifyCase *VerifyCase) {
+	if len(verifyCase.Includes) > 0 {
+		if verifyCase.Expected != "" || verifyCase.Query != "" {
+			v.reportf(src, path, "includes and query/expected only support selecting one of them in a case")
+		}
+		for idx, include := range verifyCase.Includes {
+			includePath := util.ResolveAbsWithBase(include, src.file)
+			if !util.PathExist(includePath) {
+				v.reportf(src, fmt.Sprintf("%s.includes[%d]", path, idx), "reuse case config file %s does not exist", includePath)
+				continue
+			}
+			v.validateCasesFile(includePath)
+		}
+		return
+	}
+
+	if verifyCase.Expected == "" {
+		v.reportf(src, path, "expected should be specified")
+	} else if expected := util.ResolveAbsWithBase(verifyCase.Expected, src.file); !util.PathExist(expected) {
+		v.reportf(src, path+".expected", "expected file %s does not exist", expected)
+	} else {
+		v.checkExpectedTemplate(expected)
+	}
+	if verifyCase.Actual != "" && verifyCase.Query != "" {
+		v.reportf(src, path, "actual and query only support selecting one of them in a case")
+	}
+}
+
+// checkExpectedTemplate parses the expected file the same way the verifier does, reporting the line of the mistake.
+func (v *validator) checkExpectedTemplate(file string) {
+	if v.visited[file] {
+		return
+	}
+	v.visited[file] = true
+
+	data, ok := v.readFile(file)
+	if !ok {
+		return
+	}
+	if err := verifier.ParseTemplate(file, string(data)); err != nil {
+		e := &ValidationError{File: file, Msg: err.Error()}
+		if groups := templateErrorLineRegex.FindStringSubmatch(err.Error()); groups != nil {
+			e.Line, _ = strconv.Atoi(groups[1])
+			e.Msg = groups[2]
+		}
+		v.errs = append(v.errs, e)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// lineIndex maps key paths such as "setup.steps[0].wait[1].resource" to the file and line they are declared at.
+type lineIndex map[string]position
+
+type position struct {
+	file string
+	line int
+}
+
+func buildLineIndex(file string, data []byte) lineIndex {
+	idx := make(lineIndex)
+	var root yaml3.Node
+	if err := yaml3.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return idx
+	}
+	idx.walk(file, root.Content[0], "")
+	return idx
+}
+
+func (idx lineIndex) walk(file string, node *yaml3.Node, path string) {
+	switch node.Kind {
+	case yaml3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := key.Value
+			if path != "" {
+				keyPath = path + "." + key.Value
+			}
+			idx[keyPath] = position{file: file, line: key.Line}
+			idx.walk(file, value, keyPath)
+		}
+	case yaml3.SequenceNode:
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			idx[itemPath] = position{file: file, line: item.Line}
+			idx.walk(file, item, itemPath)
+		}
+	case yaml3.AliasNode:
+		if node.Alias != nil {
+			idx.walk(file, node.Alias, path)
+		}
+	}
+}
+
+// extend merges the index of the base file into this one, the same way mergeConfigChain merges the files:
+// the keys declared in both files point to this file, and the list items of this file follow the base ones.
+func (idx lineIndex) extend(base lineIndex) lineIndex {
+	merged := make(lineIndex, len(idx)+len(base))
+	for path, pos := range base {
+		merged[path] = pos
+	}
+	for path, pos := range idx {
+		merged[base.shift(path)] = pos
+	}
+	return merged
+}
+
+// shift moves the list indexes in the path after the items the lists already have in this index.
+func (idx lineIndex) shift(path string) string {
+	var shifted strings.Builder
+	last := 0
+	for _, loc := range listIndexRegex.FindAllStringSubmatchIndex(path, -1) {
+		shifted.WriteString(path[last:loc[0]])
+		prefix := shifted.String()
+		count := 0
+		for {
+			if _, ok := idx[fmt.Sprintf("%s[%d]", prefix, count)]; !ok {
+				break
+			}
+			count++
+		}
+		i, _ := strconv.Atoi(path[loc[2]:loc[3]])
+		fmt.Fprintf(&shifted, "[%d]", count+i)
+		last = loc[1]
+	}
+	shifted.WriteString(path[last:])
+	return shifted.String()
+}
+
+// position returns the position of the path, or of its closest declared parent if the path itself is not declared.
+func (idx lineIndex) position(path string) (position, bool) {
+	for path != "" {
+		if pos, ok := idx[path]; ok {
+			return pos, true
+		}
+		path = path[:max(strings.LastIndexAny(path, ".["), 0)]
+	}
+	return position{}, false
+}
@@ -0,0 +1,486 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		wantErrs []string
+	}{
+		{
+			name: "Valid config with included cases",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: compose
+  file: docker-compose.yml
+  timeout: 20m
+cleanup:
+  on: always
+trigger:
+  action: http
+  interval: 3s
+  url: http://localhost:8080
+verify:
+  retry:
+    count: 3
+    interval: 10s
+  cases:
+    - query: echo foo
+      expected: expected.yaml
+    - includes:
+        - cases/cases.yaml
+`,
+				"expected.yaml": "foo",
+				"cases/cases.yaml": `
+cases:
+  - actual: actual.yaml
+    expected: expected.yaml
+`,
+				"cases/expected.yaml": "foo",
+			},
+		},
+		{
+			name: "Unknown keys are reported with their line",
+			files: map[string]string{
+				"e2e.yaml": `setup:
+  env: kind
+  file: kind.yaml
+  kind:
+    import-image:
+      - busybox
+verify:
+  retry:
+    intervl: 10s
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:5: unknown key "import-image"`,
+				`e2e.yaml:9: unknown key "intervl"`,
+			},
+		},
+		{
+			name: "Invalid values are reported with their line",
+			files: map[string]string{
+				"e2e.yaml": `setup:
+  env: docker
+  timeout: 20minutes
+  steps:
+    - name: both
+      path: manifest.yaml
+      command: kubectl apply -f manifest.yaml
+      wait:
+        - resource: pod/foo
+          label-selector: app=foo
+cleanup:
+  on: sometimes
+  collect:
+    on: maybe
+trigger:
+  action: grpc
+verify:
+  retry:
+    interval: soon
+  cases:
+    - query: echo foo
+      expected: not-exist.yaml
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:2: setup.env: unsupported env "docker"`,
+				`e2e.yaml:3: setup.timeout:`,
+				`e2e.yaml:5: setup.steps[0]: one of path, kustomize, command or helm should be specified`,
+				`e2e.yaml:10: setup.steps[0].wait[0].label-selector:`,
+				`e2e.yaml:12: cleanup.on: unsupported value "sometimes"`,
+				`e2e.yaml:14: cleanup.collect.on: unsupported value "maybe"`,
+				`e2e.yaml:16: trigger.action: unsupported action "grpc"`,
+				`e2e.yaml:19: verify.retry.interval:`,
+				`e2e.yaml:22: verify.cases[0].expected: expected file`,
+			},
+		},
+		{
+			name: "Problems in included files are reported with the included file",
+			files: map[string]string{
+				"e2e.yaml": `
+verify:
+  cases:
+    - includes:
+        - cases.yaml
+        - missing.yaml
+`,
+				"cases.yaml": `cases:
+  - query: echo foo
+    expect: expected.yaml
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:6: verify.cases[0].includes[1]: reuse case config file`,
+				`cases.yaml:3: unknown key "expect"`,
+				`cases.yaml:2: cases[0]: expected should be specified`,
+			},
+		},
+		{
+			name: "Problems in extended files are reported with the file declaring them",
+			files: map[string]string{
+				"e2e.yaml": `
+extends: base/base.yaml
+setup:
+  file: docker-compose.yml
+verify:
+  cases:
+    - query: echo bar
+`,
+				"base/base.yaml": `
+setup:
+  env: compose
+cleanup:
+  on: sometimes
+verify:
+  cases:
+    - query: echo foo
+      expected: expected.yaml
+`,
+				"base/expected.yaml": "foo",
+			},
+			wantErrs: []string{
+				`base.yaml:5: cleanup.on: unsupported value "sometimes"`,
+				`e2e.yaml:7: verify.cases[1]: expected should be specified`,
+			},
+		},
+		{
+			name: "Circular extends",
+			files: map[string]string{
+				"e2e.yaml": `
+extends: base.yaml
+`,
+				"base.yaml": `
+extends: e2e.yaml
+`,
+			},
+			wantErrs: []string{
+				`base.yaml:2: extends: config file`,
+			},
+		},
+		{
+			name: "Problems in included steps files are reported with the included file",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: compose
+  file: docker-compose.yml
+  steps:
+    - includes:
+        - steps/es.yaml
+      command: echo foo
+`,
+				"steps/es.yaml": `
+steps:
+  - name: install es
+    command: make es
+    wait:
+      - for: condition=Ready
+  - includes:
+      - oap.yaml
+`,
+				"steps/oap.yaml": `
+steps:
+  - includes:
+      - es.yaml
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:6: setup.steps[0]: includes and path/kustomize/command/helm/wait only support selecting one of them in a step`,
+				`es.yaml:6: steps[0].wait[0]: one of resource, tcp, http, command or log should be specified`,
+				`oap.yaml:4: steps[0].includes[0]: reuse step config file`,
+			},
+		},
+		{
+			name: "Local env only runs commands",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: local
+  file: docker-compose.yml
+  steps:
+    - name: start server
+      command: ./server
+      background: true
+      wait:
+        - tcp: localhost:8080
+        - http: http://localhost:8080/healthz
+          tcp: localhost:8080
+    - name: deploy
+      path: manifest.yaml
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:2: setup: file and kubeconfig are not supported by local env`,
+				`e2e.yaml:11: setup.steps[0].wait[1]: one of resource, tcp, http, command or log should be specified`,
+				`e2e.yaml:14: setup.steps[1].path: manifests are not supported by local env`,
+			},
+		},
+		{
+			name: "Kind steps are checked by their kinds",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: kind
+  file: kind.yaml
+  steps:
+    - name: install oap
+      helm:
+        chart: skywalking
+        repo: oci://registry-1.docker.io/apache
+        namespace: skywalking
+        values: [values.yaml]
+        set:
+          oap.image.tag: ${OAP_TAG}
+    - name: both
+      command: helm install
+      helm:
+        chart: ./chart
+        release: foo
+    - name: replace
+      path: manifest.yaml
+      mode: upsert
+      render: envsubst
+    - name: apply
+      command: kubectl apply -f manifest.yaml
+      mode: apply
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:7: setup.steps[0].helm: release should be specified`,
+				`e2e.yaml:14: setup.steps[1]: one of path, kustomize, command or helm should be specified`,
+				`e2e.yaml:21: setup.steps[2].mode: unsupported mode "upsert"`,
+				`e2e.yaml:22: setup.steps[2].render: unsupported render "envsubst"`,
+				`e2e.yaml:25: setup.steps[3].mode: mode is only supported by path and kustomize steps`,
+			},
+		},
+		{
+			name: "Step needs are checked",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: local
+  steps:
+    - id: es
+      command: ./es
+    - id: oap
+      needs: [es, ui]
+      command: ./oap
+    - id: -ui
+      command: ./ui
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:4: setup.steps: step oap needs unknown step ui`,
+				`e2e.yaml:10: setup.steps[2].id: invalid step id "-ui"`,
+			},
+		},
+		{
+			name: "Step timeout, retry and condition are checked",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: local
+  steps:
+    - name: pull
+      command: docker pull busybox
+      timeout: soon
+      retry:
+        count: 3
+        backoff: 0.5
+      if: "{{ eq .Env.PULL"
+    - name: server
+      command: ./server
+      background: true
+      retry:
+        count: 1
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:7: setup.steps[0].timeout:`,
+				`e2e.yaml:8: setup.steps[0].retry: backoff should be >= 1`,
+				`e2e.yaml:11: setup.steps[0].if:`,
+				`e2e.yaml:15: setup.steps[1].retry: background steps could not be retried`,
+			},
+		},
+		{
+			name: "Setup waits are checked",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: compose
+  file: docker-compose.yml
+  wait:
+    - http: http://localhost:12800/graphql
+      method: POST
+      body: '{"query":"{ version }"}'
+      expected-status: 200
+      expected-body: '"version"'
+      interval: 5s
+    - tcp: localhost:9200
+      expected-body: green
+    - resource: pod
+      interval: 1s
+    - command: curl localhost:8080
+      interval: 0s
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:12: setup.wait[1]: method, headers, body, expected-status and expected-body are only supported by http wait`,
+				`e2e.yaml:14: setup.wait[2].resource: resource waits are only supported by kind env`,
+				`e2e.yaml:15: setup.wait[2].interval: interval is only supported by tcp, http, command and log waits`,
+				`e2e.yaml:17: setup.wait[3].interval: should be > 0`,
+			},
+		},
+		{
+			name: "Log waits are checked",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: compose
+  file: docker-compose.yml
+  wait:
+    - log:
+        service: oap
+        pattern: started
+        occurrences: 2
+    - log:
+        resource: deployment/oap
+        pattern: '(started'
+    - log:
+        service: oap
+        namespace: default
+        occurrences: -1
+      tcp: localhost:9200
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:12: setup.wait[1].log.pattern: invalid regex:`,
+				`e2e.yaml:11: setup.wait[1].log.resource: resource logs are only supported by kind env`,
+				`e2e.yaml:15: setup.wait[2].log.namespace: namespace is only supported by resource`,
+				`e2e.yaml:13: setup.wait[2].log: pattern should be specified`,
+				`e2e.yaml:16: setup.wait[2].log.occurrences: should be >= 0, but was -1`,
+				`e2e.yaml:13: setup.wait[2]: one of resource, tcp, http, command or log should be specified`,
+			},
+		},
+		{
+			name: "Compose options are only supported by compose env",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: local
+  compose:
+    one-shot:
+      - init-db
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:4: setup.compose: compose is only supported by compose env`,
+			},
+		},
+		{
+			name: "Containers env checks the containers",
+			files: map[string]string{
+				"e2e.yaml": `
+setup:
+  env: containers
+  containers:
+    - name: oap
+      image: apache/skywalking-oap-server
+      ports: [12800]
+      depends-on: [banyandb]
+      wait:
+        http: /healthcheck
+        log: started
+    - name: banyandb
+      depends-on: [oap]
+    - name: -ui
+      image: apache/skywalking-ui
+      ports: [8080, 8080/sctp]
+      depends-on: [nginx]
+`,
+			},
+			wantErrs: []string{
+				`e2e.yaml:4: setup.containers: containers depend on each other circularly: oap -> banyandb -> oap`,
+				`e2e.yaml:9: setup.containers[0].wait: only one of http, log or command could be specified`,
+				`e2e.yaml:12: setup.containers[1]: image should be specified`,
+				`e2e.yaml:14: setup.containers[2].name: invalid container name "-ui"`,
+				`e2e.yaml:16: setup.containers[2].ports[1]: unsupported protocol "sctp" of port "8080/sctp", should be tcp or udp`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			err := Validate(filepath.Join(dir, "e2e.yaml"))
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+			}
+			if len(errs) != len(tt.wantErrs) {
+				t.Errorf("Validate() got %d errors, want %d:\n%v", len(errs), len(tt.wantErrs), err)
+			}
+			for _, want := range tt.wantErrs {
+				found := false
+				for _, e := range errs {
+					if strings.Contains(e.Error(), want) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Validate() missing error %q in:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -32,8 +32,33 @@ const (
 	K8sClusterConfigFileName = "e2e-k8s.config"
 	DefaultWaitTimeout       = 600 * time.Second
 	SingleDefaultWaitTimeout = 30 * 60 * time.Second
+	DefaultWaitInterval      = time.Second
 	StepTypeManifest         = "manifest"
 	StepTypeCommand          = "command"
+	HelmCommand              = "helm"
+)
+
+// The operations on the manifests, the first three of them are the modes of the manifest steps.
+const (
+	ManifestCreate  = "create"
+	ManifestApply   = "apply"
+	ManifestReplace = "replace"
+	ManifestDelete  = "delete"
+
+	// FieldManager is the manager of the fields set by the manifest steps.
+	FieldManager = "skywalking-infra-e2e"
+)
+
+// The namespace isolating the run on a shared cluster.
+const (
+	IsolatedNamespacePrefix = "e2e"
+	IsolatedNamespaceEnv    = "E2E_NAMESPACE"
+)
+
+// How the manifest files are rendered before they are applied.
+const (
+	RenderEnv      = "env"
+	RenderTemplate = "template"
 )
 
 func init() {
@@ -0,0 +1,198 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// FileName is the file in the working directory the state of the setup is saved to.
+const FileName = "state.yaml"
+
+// State is what the setup leaves for the separate commands running after it, such as `e2e verify` and `e2e cleanup`.
+type State struct {
+	// ConfigFile, MatrixIndex, Env and SetupFile identify the setup, and are checked against the current config.
+	ConfigFile  string `yaml:"config-file"`
+	MatrixIndex int    `yaml:"matrix-index"`
+	Env         string `yaml:"env"`
+	SetupFile   string `yaml:"setup-file,omitempty"`
+
+	ComposeProject string            `yaml:"compose-project,omitempty"`
+	KindCluster    string            `yaml:"kind-cluster,omitempty"`
+	Kubeconfig     string            `yaml:"kubeconfig,omitempty"`
+	LogDir         string            `yaml:"log-dir"`
+	Manifests      []string          `yaml:"manifests,omitempty"`
+	HelmReleases   []HelmRelease     `yaml:"helm-releases,omitempty"`
+	Objects        []Object          `yaml:"objects,omitempty"`    // the kubernetes objects created by the manifest steps
+	Processes      []Process         `yaml:"processes,omitempty"`  // process groups of the background steps
+	Containers     map[string]string `yaml:"containers,omitempty"` // container ids by the names of the containers env
+	Networks       []string          `yaml:"networks,omitempty"`
+	Variables      map[string]string `yaml:"variables,omitempty"`
+}
+
+// HelmRelease is a release installed by the helm steps.
+type HelmRelease struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+	Order     int    `yaml:"order"` // the order created among the helm releases and the objects
+}
+
+// Process is the leader of a process group started by the background steps.
+type Process struct {
+	PID int `yaml:"pid"`
+	// StartTime identifies the process, so the cleanup doesn't signal another process reusing the pid.
+	StartTime string `yaml:"start-time,omitempty"`
+}
+
+// Object is a kubernetes object created by the manifest steps.
+type Object struct {
+	APIVersion string `yaml:"api-version"`
+	Kind       string `yaml:"kind"`
+	Namespace  string `yaml:"namespace,omitempty"`
+	Name       string `yaml:"name"`
+	Order      int    `yaml:"order"` // the order created among the helm releases and the objects
+}
+
+// NextOrder returns the order of the next helm release or object created in the cluster.
+func (s *State) NextOrder() int {
+	return len(s.HelmReleases) + len(s.Objects)
+}
+
+// Current is the state recorded by the setup of this process, or restored from the working directory.
+// It's nil if neither happens, such as running `e2e verify` against an environment set up by others.
+var Current *State
+
+// lock guards the updates of the steps running concurrently.
+var lock sync.Mutex
+
+// New creates the state of the setup according to the config.
+func New(conf *config.E2EConfig) *State {
+	cfgAbsPath, _ := filepath.Abs(util.CfgFile)
+	return &State{
+		ConfigFile:  cfgAbsPath,
+		MatrixIndex: util.MatrixIndex,
+		Env:         conf.Setup.Env,
+		SetupFile:   conf.Setup.GetFile(),
+		LogDir:      util.LogDir,
+	}
+}
+
+// Record starts recording the state of the setup.
+func Record(conf *config.E2EConfig) {
+	Current = New(conf)
+}
+
+// Save writes the state and the variables of the environment store into the working directory.
+func (s *State) Save() error {
+	s.Variables = util.Env.Vars()
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path(), data, 0o600)
+}
+
+// Load reads the state from the working directory, it returns nil if the setup has not been run.
+func Load() (*State, error) {
+	data, err := os.ReadFile(path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	s := &State{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("unmarshal state file %s error: %v", path(), err)
+	}
+	return s, nil
+}
+
+// Restore loads the state saved by the setup and exports its variables into the environment store,
+// it refuses to restore the state of another config.
+func Restore(conf *config.E2EConfig) error {
+	s, err := Load()
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		logger.Log.Debugf("no state file found in %s, the environment is not set up by e2e setup", util.WorkDir)
+		return nil
+	}
+	if err := s.Check(New(conf)); err != nil {
+		return fmt.Errorf("%v, the state file %s is saved by the setup of another config, "+
+			"please clean it up or use another working directory", err, path())
+	}
+
+	util.Env.SetAll(s.Variables)
+	if s.LogDir != "" && s.LogDir != util.LogDir {
+		logger.Log.Infof("use the log directory %s of the setup", s.LogDir)
+		util.LogDir = s.LogDir
+	}
+	Current = s
+	return nil
+}
+
+// Check checks whether the state is saved by the setup of the expected one.
+func (s *State) Check(expected *State) error {
+	checks := []struct {
+		name            string
+		saved, expected any
+	}{
+		{"config file", s.ConfigFile, expected.ConfigFile},
+		{"matrix index", s.MatrixIndex, expected.MatrixIndex},
+		{"env", s.Env, expected.Env},
+		{"setup file", s.SetupFile, expected.SetupFile},
+	}
+	for _, check := range checks {
+		if check.saved != check.expected {
+			return fmt.Errorf("the %s of the setup is %v, but got %v", check.name, check.saved, check.expected)
+		}
+	}
+	return nil
+}
+
+// Update updates the state being recorded, it does nothing if the setup is not recorded.
+func Update(update func(s *State)) {
+	lock.Lock()
+	defer lock.Unlock()
+	if Current != nil {
+		update(Current)
+	}
+}
+
+// Remove deletes the state file after the environment is cleaned up.
+func Remove() error {
+	Current = nil
+	if err := os.Remove(path()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func path() string {
+	return filepath.Join(util.WorkDir, FileName)
+}
@@ -0,0 +1,86 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func TestRestore(t *testing.T) {
+	util.WorkDir = t.TempDir()
+	util.CfgFile = "e2e.yaml"
+	util.LogDir = "/tmp/logs"
+	conf := &config.E2EConfig{Setup: config.Setup{Env: "compose"}}
+
+	// nothing to restore before the setup
+	if err := Restore(conf); err != nil || Current != nil {
+		t.Fatalf("Restore() = %v, Current = %v, want nothing restored", err, Current)
+	}
+
+	Record(conf)
+	Update(func(s *State) { s.ComposeProject = "skywalking_e2e" })
+	util.Env.Set("E2E_TEST_STATE_HOST", "127.0.0.1")
+	if err := Current.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	Current = nil
+	util.Env = util.NewEnvStore()
+	if err := Restore(conf); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if Current == nil || Current.ComposeProject != "skywalking_e2e" {
+		t.Errorf("Current = %+v", Current)
+	}
+	if got := util.Env.Get("E2E_TEST_STATE_HOST"); got != "127.0.0.1" {
+		t.Errorf("E2E_TEST_STATE_HOST = %q", got)
+	}
+
+	tests := []struct {
+		name    string
+		conf    *config.E2EConfig
+		cfgFile string
+		index   int
+		wantErr string
+	}{
+		{name: "another env", conf: &config.E2EConfig{Setup: config.Setup{Env: "kind"}}, cfgFile: "e2e.yaml", index: -1, wantErr: "the env of the setup is compose, but got kind"},
+		{name: "another config file", conf: conf, cfgFile: "other.yaml", index: -1, wantErr: "the config file of the setup"},
+		{name: "another matrix combination", conf: conf, cfgFile: "e2e.yaml", index: 1, wantErr: "the matrix index of the setup is -1, but got 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			util.CfgFile, util.MatrixIndex = tt.cfgFile, tt.index
+			defer func() { util.CfgFile, util.MatrixIndex = "e2e.yaml", -1 }()
+
+			if err := Restore(tt.conf); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Restore() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := Remove(); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if s, err := Load(); err != nil || s != nil {
+		t.Errorf("Load() = %v, %v after removed", s, err)
+	}
+}
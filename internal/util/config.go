@@ -30,6 +30,10 @@ var (
 	WorkDir   string
 	LogDir    string
 	BatchMode bool
+	// MatrixIndex is the index of the matrix combination to run, negative if no combination is selected.
+	MatrixIndex = -1
+	// Overrides are the `path=value` pairs overriding the keys of the config file.
+	Overrides []string
 )
 
 // ResolveAbs resolves the relative path (relative to CfgFile) to an absolute file path.
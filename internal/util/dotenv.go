@@ -0,0 +1,335 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseDotenv parses the content of a dotenv file, it supports:
+//   - comments starting with `#`, and the `export` prefix of the keys,
+//   - unquoted values, with the inline comments after a whitespace removed,
+//   - single quoted values, which are kept as they are, and could span multiple lines,
+//   - double quoted values, which could span multiple lines, with the escapes `\n`, `\r`, `\t`, `\"`, `\\`, `\$` and "\`",
+//   - `$VAR`, `${VAR}`, `${VAR:-default}` and `${VAR-default}` in the unquoted and double quoted values,
+//     `${VAR:default}` is also supported for compatibility, the same as `${VAR:-default}`.
+//
+// The variables are looked up from the ones declared before them in the file, and then by the lookup function.
+func ParseDotenv(content string, lookup func(string) (string, bool)) (map[string]string, error) {
+	p := &dotenvParser{src: []rune(strings.ReplaceAll(content, "\r\n", "\n")), line: 1, lookup: lookup, vars: make(map[string]string)}
+	for {
+		p.skipBlankAndComments()
+		if p.eof() {
+			return p.vars, nil
+		}
+		if err := p.parseLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReadDotenvFile parses the dotenv file, the variables are looked up from the environment store.
+func ReadDotenvFile(file string) (map[string]string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := ParseDotenv(string(content), Env.Lookup)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", file, err)
+	}
+	return vars, nil
+}
+
+// escapedDollar stands for the escaped `$` until the variables are expanded.
+const escapedDollar = '\uE000'
+
+type dotenvParser struct {
+	src    []rune
+	pos    int
+	line   int
+	lookup func(string) (string, bool)
+	vars   map[string]string
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) next() rune {
+	r := p.src[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+func (p *dotenvParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotenvParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func (p *dotenvParser) skipBlankAndComments() {
+	for !p.eof() {
+		p.skipSpaces()
+		switch p.peek() {
+		case '\n':
+			p.next()
+		case '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+func (p *dotenvParser) parseLine() error {
+	line := p.line
+	start := p.pos
+	for !p.eof() && p.peek() != '=' && p.peek() != '\n' {
+		p.next()
+	}
+	if p.peek() != '=' {
+		return fmt.Errorf("%d: missing `=` after %q", line, strings.TrimSpace(string(p.src[start:p.pos])))
+	}
+	key := strings.TrimSpace(string(p.src[start:p.pos]))
+	if rest, ok := strings.CutPrefix(key, "export "); ok {
+		key = strings.TrimSpace(rest)
+	}
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return fmt.Errorf("%d: invalid key %q", line, key)
+	}
+	p.next() // skip `=`
+	p.skipSpaces()
+
+	var value string
+	var err error
+	switch p.peek() {
+	case '\'':
+		value, err = p.parseSingleQuoted()
+	case '"':
+		value, err = p.parseDoubleQuoted()
+	default:
+		value, err = p.parseUnquoted()
+	}
+	if err != nil {
+		return err
+	}
+	p.vars[key] = value
+	return nil
+}
+
+func (p *dotenvParser) parseSingleQuoted() (string, error) {
+	line := p.line
+	p.next()
+	start := p.pos
+	for !p.eof() && p.peek() != '\'' {
+		p.next()
+	}
+	if p.eof() {
+		return "", fmt.Errorf("%d: unterminated single quoted value", line)
+	}
+	value := string(p.src[start:p.pos])
+	p.next()
+	return value, p.endOfValue()
+}
+
+func (p *dotenvParser) parseDoubleQuoted() (string, error) {
+	line := p.line
+	p.next()
+	var raw strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("%d: unterminated double quoted value", line)
+		}
+		r := p.next()
+		if r == '"' {
+			break
+		}
+		if r != '\\' || p.eof() {
+			raw.WriteRune(r)
+			continue
+		}
+		switch escaped := p.next(); escaped {
+		case 'n':
+			raw.WriteRune('\n')
+		case 'r':
+			raw.WriteRune('\r')
+		case 't':
+			raw.WriteRune('\t')
+		case '"', '\\', '`':
+			raw.WriteRune(escaped)
+		case '$':
+			raw.WriteRune(escapedDollar)
+		default:
+			raw.WriteRune('\\')
+			raw.WriteRune(escaped)
+		}
+	}
+	value, err := p.expand(raw.String())
+	if err != nil {
+		return "", err
+	}
+	return value, p.endOfValue()
+}
+
+func (p *dotenvParser) parseUnquoted() (string, error) {
+	start := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		if p.peek() == '#' && p.pos > start && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			break
+		}
+		p.next()
+	}
+	value := strings.TrimSpace(string(p.src[start:p.pos]))
+	p.skipLine()
+	return p.expand(strings.ReplaceAll(value, `\$`, string(escapedDollar)))
+}
+
+// endOfValue makes sure there is nothing but comments after the quoted value.
+func (p *dotenvParser) endOfValue() error {
+	p.skipSpaces()
+	if !p.eof() && p.peek() != '\n' && p.peek() != '#' {
+		return p.errorf("unexpected %q after the quoted value", p.peek())
+	}
+	p.skipLine()
+	return nil
+}
+
+// expand replaces the variables in the value, the escaped `$` is kept as a literal `$`.
+func (p *dotenvParser) expand(value string) (string, error) {
+	var result strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '$' && i+1 < len(value) && value[i+1] == '{':
+			end := matchingBrace(value, i+2)
+			if end < 0 {
+				return "", p.errorf("unterminated variable in %q", value)
+			}
+			expanded, err := p.expandBraced(value[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(expanded)
+			i = end
+		case c == '$' && i+1 < len(value) && isEnvNameChar(value[i+1], true):
+			j := i + 1
+			for j < len(value) && isEnvNameChar(value[j], false) {
+				j++
+			}
+			result.WriteString(p.get(value[i+1 : j]))
+			i = j - 1
+		default:
+			result.WriteByte(c)
+		}
+	}
+	return strings.ReplaceAll(result.String(), string(escapedDollar), "$"), nil
+}
+
+// expandBraced expands the content of `${...}`, such as `VAR`, `VAR:-default` or `VAR-default`.
+func (p *dotenvParser) expandBraced(content string) (string, error) {
+	end := 0
+	for end < len(content) && isEnvNameChar(content[end], end == 0) {
+		end++
+	}
+	name, rest := content[:end], content[end:]
+	if name == "" {
+		return "", p.errorf("invalid variable ${%s}", content)
+	}
+
+	value, ok := p.lookupVar(name)
+	switch {
+	case rest == "":
+		return value, nil
+	case strings.HasPrefix(rest, ":-"):
+		if value != "" {
+			return value, nil
+		}
+		return p.expand(rest[2:])
+	case strings.HasPrefix(rest, "-"):
+		if ok {
+			return value, nil
+		}
+		return p.expand(rest[1:])
+	case strings.HasPrefix(rest, ":"):
+		if value != "" {
+			return value, nil
+		}
+		return p.expand(rest[1:])
+	default:
+		return "", p.errorf("invalid variable ${%s}", content)
+	}
+}
+
+func (p *dotenvParser) lookupVar(name string) (string, bool) {
+	if value, ok := p.vars[name]; ok {
+		return value, true
+	}
+	if p.lookup != nil {
+		return p.lookup(name)
+	}
+	return "", false
+}
+
+func (p *dotenvParser) get(name string) string {
+	value, _ := p.lookupVar(name)
+	return value
+}
+
+// matchingBrace returns the index of the `}` closing the `${` before start, or -1 if it's not closed.
+func matchingBrace(value string, start int) int {
+	depth := 1
+	for i := start; i < len(value); i++ {
+		switch {
+		case value[i] == '$' && i+1 < len(value) && value[i+1] == '{':
+			depth++
+			i++
+		case value[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isEnvNameChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
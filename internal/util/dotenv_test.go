@@ -0,0 +1,124 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		env := map[string]string{"HOME": "/home/e2e", "EMPTY": ""}
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "Unquoted values with comments and export",
+			content: `
+# comment
+FOO=bar
+export BAR = baz qux # inline comment
+HASH=a#b
+EMPTY_VALUE=
+`,
+			want: map[string]string{"FOO": "bar", "BAR": "baz qux", "HASH": "a#b", "EMPTY_VALUE": ""},
+		},
+		{
+			name: "Quoted values",
+			content: `
+SINGLE='$HOME \n "kept"' # comment
+DOUBLE="say \"hi\"\tto\n$HOME \$HOME \\"
+`,
+			want: map[string]string{
+				"SINGLE": `$HOME \n "kept"`,
+				"DOUBLE": "say \"hi\"\tto\n/home/e2e $HOME \\",
+			},
+		},
+		{
+			name:    "Multi-line values",
+			content: "CERT=\"-----BEGIN-----\nabc\n-----END-----\"\nJSON='{\n  \"k\": \"v\"\n}'\nNEXT=1\n",
+			want: map[string]string{
+				"CERT": "-----BEGIN-----\nabc\n-----END-----",
+				"JSON": "{\n  \"k\": \"v\"\n}",
+				"NEXT": "1",
+			},
+		},
+		{
+			name: "Interpolation",
+			content: `
+A=${HOME}/a
+B=$A/b
+C=${MISSING:-default}
+D=${EMPTY:-default}
+E=${EMPTY-default}
+F=${MISSING:legacy}
+G=${MISSING:-${HOME}}
+`,
+			want: map[string]string{
+				"A": "/home/e2e/a",
+				"B": "/home/e2e/a/b",
+				"C": "default",
+				"D": "default",
+				"E": "",
+				"F": "legacy",
+				"G": "/home/e2e",
+			},
+		},
+		{
+			name:    "Unterminated quote",
+			content: "A=1\nB=\"abc\n",
+			wantErr: `2: unterminated double quoted value`,
+		},
+		{
+			name:    "Missing equal sign",
+			content: "A=1\nB\n",
+			wantErr: "2: missing `=`",
+		},
+		{
+			name:    "Content after quoted value",
+			content: `A="abc" def`,
+			wantErr: `1: unexpected 'd' after the quoted value`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv(tt.content, lookup)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDotenv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDotenv() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDotenv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
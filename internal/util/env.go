@@ -0,0 +1,157 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnvFileName is the file in the working directory the environment store is saved to.
+const EnvFileName = ".env"
+
+// EnvStore holds the environment variables exported by the setup and the commands, such as `<service>_host`,
+// on top of the environment of the e2e process, which is never changed by them.
+type EnvStore struct {
+	lock sync.RWMutex
+	vars map[string]string
+}
+
+// Env is the environment store shared by all the components.
+var Env = NewEnvStore()
+
+func NewEnvStore() *EnvStore {
+	return &EnvStore{vars: make(map[string]string)}
+}
+
+// Set exports the variable into the store.
+func (s *EnvStore) Set(key, value string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.vars[key] = value
+}
+
+// SetAll exports all the variables into the store.
+func (s *EnvStore) SetAll(vars map[string]string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key, value := range vars {
+		s.vars[key] = value
+	}
+}
+
+// Lookup looks up the variable from the store, and then from the environment of the e2e process.
+func (s *EnvStore) Lookup(key string) (string, bool) {
+	s.lock.RLock()
+	value, ok := s.vars[key]
+	s.lock.RUnlock()
+	if ok {
+		return value, true
+	}
+	return os.LookupEnv(key)
+}
+
+// Get returns the value of the variable, or empty if it's not found.
+func (s *EnvStore) Get(key string) string {
+	value, _ := s.Lookup(key)
+	return value
+}
+
+// Vars returns a copy of the variables exported into the store.
+func (s *EnvStore) Vars() map[string]string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	vars := make(map[string]string, len(s.vars))
+	for key, value := range s.vars {
+		vars[key] = value
+	}
+	return vars
+}
+
+// Snapshot returns the environment of the e2e process overridden by the store, in the form of `key=value`,
+// it's isolated from the later changes, and could be used as the environment of a sub-process.
+func (s *EnvStore) Snapshot() []string {
+	vars := s.Vars()
+	environ := make([]string, 0, len(vars))
+	for _, env := range os.Environ() {
+		if key, _, ok := strings.Cut(env, "="); ok {
+			if _, exported := vars[key]; exported {
+				continue
+			}
+		}
+		environ = append(environ, env)
+	}
+	for key, value := range vars {
+		environ = append(environ, key+"="+value)
+	}
+	return environ
+}
+
+// Environ returns the environment of the e2e process overridden by the store, keyed by the variable names.
+func (s *EnvStore) Environ() map[string]string {
+	environ := make(map[string]string)
+	for _, env := range os.Environ() {
+		if key, value, ok := strings.Cut(env, "="); ok {
+			environ[key] = value
+		}
+	}
+	for key, value := range s.Vars() {
+		environ[key] = value
+	}
+	return environ
+}
+
+// Expand replaces ${var} or $var in the string according to the store and the environment of the e2e process.
+func (s *EnvStore) Expand(str string) string {
+	return os.Expand(str, s.Get)
+}
+
+// Save writes the variables of the store into the working directory, which could be dumped by `e2e env`.
+func (s *EnvStore) Save() error {
+	return os.WriteFile(filepath.Join(WorkDir, EnvFileName), []byte(FormatDotenv(s.Vars())), 0o600)
+}
+
+// ExpandEnv replaces ${var} or $var in the string according to the environment store.
+func ExpandEnv(str string) string {
+	return Env.Expand(str)
+}
+
+// FormatDotenv formats the variables in the dotenv format sorted by the keys, the values are double quoted,
+// so they could also be sourced by shells.
+func FormatDotenv(vars map[string]string) string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteString(`="`)
+		b.WriteString(dotenvEscaper.Replace(vars[key]))
+		b.WriteString("\"\n")
+	}
+	return b.String()
+}
+
+var dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
@@ -21,9 +21,11 @@ package util
 import (
 	"bufio"
 	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
@@ -35,6 +37,8 @@ type ResourceLogFollower struct {
 	basePath   string
 	followLock *sync.RWMutex
 	following  map[string]bool
+	logFiles   map[string]bool
+	writers    []*os.File
 }
 
 func NewResourceLogFollower(ctx context.Context, basePath string) *ResourceLogFollower {
@@ -45,6 +49,7 @@ func NewResourceLogFollower(ctx context.Context, basePath string) *ResourceLogFo
 		basePath:   basePath,
 		followLock: &sync.RWMutex{},
 		following:  make(map[string]bool),
+		logFiles:   make(map[string]bool),
 	}
 }
 
@@ -58,8 +63,15 @@ func (l *ResourceLogFollower) BuildLogWriter(path string) (*os.File, error) {
 			return nil, err
 		}
 	}
-
-	return os.Create(logFile)
+	writer, err := os.Create(logFile)
+	if err != nil {
+		return nil, err
+	}
+	l.followLock.Lock()
+	l.logFiles[logFile] = true
+	l.writers = append(l.writers, writer)
+	l.followLock.Unlock()
+	return writer, nil
 }
 
 func (l *ResourceLogFollower) ConsumeLog(logWriter *os.File, stream io.ReadCloser) <-chan struct{} {
@@ -101,8 +113,36 @@ func (l *ResourceLogFollower) IsFollowed(path string) bool {
 	return l.following[l.buildLogFilename(path)]
 }
 
+// LogFiles returns the log files built by the follower, sorted by the paths.
+func (l *ResourceLogFollower) LogFiles() []string {
+	l.followLock.RLock()
+	defer l.followLock.RUnlock()
+	files := make([]string, 0, len(l.logFiles))
+	for file := range l.logFiles {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// LogFile returns the log file of the path, which may not be built yet.
+func (l *ResourceLogFollower) LogFile(path string) string {
+	return l.buildLogFilename(path)
+}
+
+// Close stops following the logs, and closes the log files built by the follower.
 func (l *ResourceLogFollower) Close() {
 	l.cancelFunc()
+
+	l.followLock.Lock()
+	defer l.followLock.Unlock()
+	for _, writer := range l.writers {
+		// some of them are closed by their users already
+		if err := writer.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+			logger.Log.Warnf("failed to close log file %s: %v", writer.Name(), err)
+		}
+	}
+	l.writers = nil
 }
 
 func (l *ResourceLogFollower) buildLogFilename(path string) string {
@@ -0,0 +1,42 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestResourceLogFollowerClose(t *testing.T) {
+	follower := NewResourceLogFollower(context.Background(), t.TempDir())
+	writer, err := follower.BuildLogWriter("oap/std.log")
+	if err != nil {
+		t.Fatalf("BuildLogWriter() error = %v", err)
+	}
+
+	follower.Close()
+	if follower.Ctx.Err() == nil {
+		t.Errorf("the context of the follower is not cancelled")
+	}
+	if _, err := writer.WriteString("started\n"); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after Close() error = %v, want %v", err, os.ErrClosed)
+	}
+}
@@ -0,0 +1,129 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEnvStore(t *testing.T) {
+	os.Setenv("E2E_TEST_STORE_OS", "os")
+	defer os.Unsetenv("E2E_TEST_STORE_OS")
+
+	store := NewEnvStore()
+	store.Set("E2E_TEST_STORE_HOST", "127.0.0.1")
+	store.SetAll(map[string]string{"E2E_TEST_STORE_PORT": "8080", "E2E_TEST_STORE_OS": "store"})
+
+	if got := store.Get("E2E_TEST_STORE_OS"); got != "store" {
+		t.Errorf("Get() = %q, the store should take precedence over the process", got)
+	}
+	if got := os.Getenv("E2E_TEST_STORE_HOST"); got != "" {
+		t.Errorf("the store is exported to the process, got %q", got)
+	}
+	if got := store.Expand("http://${E2E_TEST_STORE_HOST}:$E2E_TEST_STORE_PORT"); got != "http://127.0.0.1:8080" {
+		t.Errorf("Expand() = %q", got)
+	}
+
+	snapshot := store.Snapshot()
+	store.Set("E2E_TEST_STORE_PORT", "9090")
+	environ := map[string]string{}
+	for _, env := range snapshot {
+		key, val, _ := strings.Cut(env, "=")
+		if _, ok := environ[key]; ok {
+			t.Errorf("duplicated variable %s in the snapshot", key)
+		}
+		environ[key] = val
+	}
+	if environ["E2E_TEST_STORE_PORT"] != "8080" || environ["E2E_TEST_STORE_OS"] != "store" {
+		t.Errorf("Snapshot() = %v", environ)
+	}
+}
+
+func TestFormatDotenv(t *testing.T) {
+	vars := map[string]string{
+		"PLAIN":     "value",
+		"MULTILINE": "line 1\nline 2",
+		"QUOTED":    "\"quoted\" 'single' $HOME `cmd` \\ back",
+	}
+	content := FormatDotenv(vars)
+	if want := "MULTILINE=\"line 1\nline 2\"\nPLAIN=\"value\"\n"; content[:len(want)] != want {
+		t.Errorf("FormatDotenv() = %q, the keys should be sorted", content)
+	}
+	parsed, err := ParseDotenv(content, func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatalf("ParseDotenv() error = %v", err)
+	}
+	if !reflect.DeepEqual(parsed, vars) {
+		t.Errorf("ParseDotenv(FormatDotenv()) = %v, want %v", parsed, vars)
+	}
+}
+
+func TestEnvStoreSave(t *testing.T) {
+	WorkDir = t.TempDir()
+	store := NewEnvStore()
+	store.Set("E2E_TEST_SAVED", "a \"b\" c")
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	vars, err := ReadDotenvFile(filepath.Join(WorkDir, EnvFileName))
+	if err != nil {
+		t.Fatalf("ReadDotenvFile() error = %v", err)
+	}
+	if vars["E2E_TEST_SAVED"] != "a \"b\" c" {
+		t.Errorf("saved variables = %v", vars)
+	}
+}
+
+func TestExecuteCommandConcurrently(t *testing.T) {
+	WorkDir = t.TempDir()
+	Env.Set("E2E_TEST_SHARED", "shared")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, stderr, err := ExecuteCommand(fmt.Sprintf(`
+test "$E2E_TEST_SHARED" = shared
+export E2E_TEST_CONCURRENT_%d=%d
+sleep 0.1`, i, i))
+			if err != nil {
+				errs[i] = fmt.Errorf("%v, stderr = %s", err, stderr)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("command %d: %v", i, err)
+		}
+		// every command exports its own variables, not the ones of the others running at the same time
+		if got := Env.Get(fmt.Sprintf("E2E_TEST_CONCURRENT_%d", i)); got != fmt.Sprint(i) {
+			t.Errorf("E2E_TEST_CONCURRENT_%d = %q", i, got)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"context"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+// RunHelm runs the helm command with the environment store, which has the `KUBECONFIG` of the cluster.
+func RunHelm(ctx context.Context, args ...string) (stdout, stderr string, err error) {
+	return RunCommand(ctx, constant.HelmCommand, args...)
+}
@@ -15,7 +15,15 @@
 # specific language governing permissions and limitations
 # under the License.
 #
+# write the exported variables in dotenv format, so that multi-line values and quotes are kept
 function finish {
-  printenv > {{ .EnvFile }}
+  local __e2e_name __e2e_value
+  for __e2e_name in $(compgen -e); do
+    __e2e_value=${!__e2e_name}
+    __e2e_value=${__e2e_value//\\/\\\\}
+    __e2e_value=${__e2e_value//\"/\\\"}
+    __e2e_value=${__e2e_value//\$/\\\$}
+    printf '%s="%s"\n' "$__e2e_name" "$__e2e_value"
+  done > "{{ .EnvFile }}"
 }
 trap finish EXIT
@@ -21,11 +21,14 @@ package util
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
-	apiv1 "k8s.io/api/admission/v1"
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -96,6 +99,24 @@ func (c *K8sClusterInfo) CopyClusterToNamespace(namespace string) *K8sClusterInf
 	}
 }
 
+// Namespace returns the namespace of the objects without one, empty means the namespace of the kubeconfig context.
+func (c *K8sClusterInfo) Namespace() string {
+	return c.namespace
+}
+
+// ResolveNamespace returns the namespace the objects, waits, ports and releases declaring the namespace are in,
+// the empty one is replaced by the namespace of the cluster, and so is `default` if the cluster is isolated in a namespace.
+func (c *K8sClusterInfo) ResolveNamespace(namespace string) string {
+	return resolveNamespace(namespace, c.namespace)
+}
+
+func resolveNamespace(namespace, isolated string) string {
+	if namespace == "" || (isolated != "" && namespace == metav1.NamespaceDefault) {
+		return isolated
+	}
+	return namespace
+}
+
 func (c *K8sClusterInfo) ToRESTConfig() (*rest.Config, error) {
 	return c.restConfig, nil
 }
@@ -173,64 +194,181 @@ func GetManifests(manifests string) (files []string, err error) {
 	return s, nil
 }
 
-// OperateManifest operates manifest in k8s cluster which kind created.
-func OperateManifest(c *kubernetes.Clientset, dc dynamic.Interface, manifest string, operation apiv1.Operation) error {
+// OperateManifest operates manifest in k8s cluster which kind created, the operation is one of constant.Manifest*.
+func OperateManifest(c *kubernetes.Clientset, dc dynamic.Interface, manifest, operation string) ([]*unstructured.Unstructured, error) {
 	b, err := os.ReadFile(manifest)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return OperateManifestContent(c, dc, b, operation, "")
+}
 
-	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(b), 100)
+// OperateManifestContent operates the objects of the YAML or JSON documents in k8s cluster,
+// and returns the objects created by it, even if it fails in the middle.
+// The namespaced objects without namespace or in the default namespace are put into the namespace if it's not empty,
+// and the ones without namespace are put into the default namespace otherwise.
+func OperateManifestContent(c *kubernetes.Clientset, dc dynamic.Interface, content []byte, operation, namespace string) (
+	created []*unstructured.Unstructured, err error) {
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(content), 100)
 	for {
 		var rawObj runtime.RawExtension
-		if err = decoder.Decode(&rawObj); err != nil {
+		if err := decoder.Decode(&rawObj); err != nil {
 			break
 		}
 
 		obj, gvk, err := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme).Decode(rawObj.Raw, nil, nil)
 		if err != nil {
-			return err
+			return created, err
 		}
 		unstructuredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
 		if err != nil {
-			return err
+			return created, err
 		}
 
 		unstructuredObj := &unstructured.Unstructured{Object: unstructuredMap}
-		apiGroupResource, err := restmapper.GetAPIGroupResources(c.Discovery())
+		dri, err := resourceInterface(c.Discovery(), dc, unstructuredObj, namespace)
 		if err != nil {
-			return err
+			return created, err
 		}
 
-		mapper := restmapper.NewDiscoveryRESTMapper(apiGroupResource)
-		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		isCreated, err := operateObject(dri, unstructuredObj, operation)
 		if err != nil {
-			return err
+			return created, fmt.Errorf("%s %s %s error: %v", operation, gvk.Kind, unstructuredObj.GetName(), err)
 		}
+		if isCreated {
+			created = append(created, unstructuredObj)
+		}
+	}
 
-		var dri dynamic.ResourceInterface
-		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
-			if unstructuredObj.GetNamespace() == "" {
-				unstructuredObj.SetNamespace(metav1.NamespaceDefault)
-			}
-			dri = dc.Resource(mapping.Resource).Namespace(unstructuredObj.GetNamespace())
+	return created, nil
+}
+
+// resourceInterface finds the resource of the object in the cluster, the namespace of it is set to the namespace
+// if it's empty or default, so the objects of the isolated namespace don't collide with the ones of other runs.
+// The namespace of the object is set to default if both of them are empty.
+func resourceInterface(d discovery.DiscoveryInterface, dc dynamic.Interface, obj *unstructured.Unstructured, namespace string) (
+	dynamic.ResourceInterface, error) {
+	apiGroupResource, err := restmapper.GetAPIGroupResources(d)
+	if err != nil {
+		return nil, err
+	}
+
+	gvk := obj.GroupVersionKind()
+	mapper := restmapper.NewDiscoveryRESTMapper(apiGroupResource)
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		obj.SetNamespace(resolveNamespace(obj.GetNamespace(), namespace))
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(metav1.NamespaceDefault)
+		}
+		return dc.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+	}
+	return dc.Resource(mapping.Resource), nil
+}
+
+// operateObject operates the object, and returns whether the object is created by it.
+func operateObject(dri dynamic.ResourceInterface, obj *unstructured.Unstructured, operation string) (bool, error) {
+	ctx := context.Background()
+	if operation == constant.ManifestDelete {
+		return false, dri.Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+	}
+	if operation == constant.ManifestCreate {
+		_, err := dri.Create(ctx, obj, metav1.CreateOptions{FieldManager: constant.FieldManager})
+		return err == nil, err
+	}
+
+	existing, err := dri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	}
+	if apierrors.IsNotFound(err) {
+		existing = nil
+	}
+
+	var result *unstructured.Unstructured
+	switch operation {
+	case constant.ManifestApply:
+		// take over the fields set by others, such as the previous `kubectl apply`, like `kubectl apply --server-side --force-conflicts`
+		result, err = dri.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{FieldManager: constant.FieldManager, Force: true})
+	case constant.ManifestReplace:
+		if existing == nil {
+			result, err = dri.Create(ctx, obj, metav1.CreateOptions{FieldManager: constant.FieldManager})
 		} else {
-			dri = dc.Resource(mapping.Resource)
+			obj.SetResourceVersion(existing.GetResourceVersion())
+			result, err = dri.Update(ctx, obj, metav1.UpdateOptions{FieldManager: constant.FieldManager})
 		}
+	default:
+		return false, fmt.Errorf("unsupported operation %s", operation)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if existing == nil {
+		logger.Log.Debugf("%s/%s created", obj.GetKind(), obj.GetName())
+	} else if diff := ObjectDiff(existing, result); diff == "" {
+		logger.Log.Debugf("%s/%s unchanged", obj.GetKind(), obj.GetName())
+	} else {
+		logger.Log.Debugf("%s/%s changed (-before +after):\n%s", obj.GetKind(), obj.GetName(), diff)
+	}
+	return existing == nil, nil
+}
+
+// DeleteObjectAndWait deletes the object with its dependents, and waits until it's gone, including its finalizers are done.
+func DeleteObjectAndWait(c *K8sClusterInfo, obj *unstructured.Unstructured, timeout time.Duration) error {
+	dri, err := resourceInterface(c.Client.Discovery(), c.Interface, obj, "")
+	if err != nil {
+		return err
+	}
+	return deleteAndWait(dri, obj.GetName(), timeout, time.Second)
+}
 
-		switch operation {
-		case apiv1.Create:
-			_, err = dri.Create(context.Background(), unstructuredObj, metav1.CreateOptions{})
-		case apiv1.Delete:
-			err = dri.Delete(context.Background(), unstructuredObj.GetName(), metav1.DeleteOptions{})
+func deleteAndWait(dri dynamic.ResourceInterface, name string, timeout, interval time.Duration) error {
+	ctx := context.Background()
+	policy := metav1.DeletePropagationForeground
+	if err := dri.Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &policy}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
 		}
+		return err
+	}
 
+	deadline := time.Now().Add(timeout)
+	for {
+		existing, err := dri.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("still exists after %v, finalizers: %v", timeout, existing.GetFinalizers())
+		}
+		time.Sleep(interval)
 	}
+}
 
-	return nil
+// ObjectDiff shows the changes from the before object to the after one, without the fields maintained by the server.
+func ObjectDiff(before, after *unstructured.Unstructured) string {
+	return cmp.Diff(withoutServerFields(before), withoutServerFields(after))
+}
+
+func withoutServerFields(obj *unstructured.Unstructured) map[string]any {
+	obj = obj.DeepCopy()
+	for _, field := range [][]string{
+		{"metadata", "managedFields"},
+		{"metadata", "resourceVersion"},
+		{"metadata", "generation"},
+		{"status"},
+	} {
+		unstructured.RemoveNestedField(obj.Object, field...)
+	}
+	return obj.Object
 }
 
 func GetKindClusterName(kindConfigFilePath string) (name string, err error) {
@@ -249,6 +387,18 @@ func GetKindClusterName(kindConfigFilePath string) (name string, err error) {
 	if nameConfig.Name == "" {
 		nameConfig.Name = constant.KindClusterDefaultName
 	}
+	// the matrix combinations could run in parallel, each of them has its own cluster
+	if MatrixIndex >= 0 {
+		nameConfig.Name = fmt.Sprintf("%s-matrix-%d", nameConfig.Name, MatrixIndex)
+	}
 
 	return nameConfig.Name, nil
 }
+
+// GetK8sClusterConfigFilePath returns the kubeconfig file path of the kind cluster created by e2e.
+func GetK8sClusterConfigFilePath() string {
+	if MatrixIndex >= 0 {
+		return fmt.Sprintf("%s-matrix-%d", constant.K8sClusterConfigFilePath, MatrixIndex)
+	}
+	return constant.K8sClusterConfigFilePath
+}
@@ -0,0 +1,237 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+func newConfigMap(storage string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "oap", "namespace": "default"},
+		"data":       map[string]any{"storage": storage},
+	}}
+}
+
+func TestOperateObject(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	tests := []struct {
+		name        string
+		existing    *unstructured.Unstructured
+		operation   string
+		wantErr     bool
+		wantCreated bool
+		wantStorage string
+	}{
+		{name: "Create a new object", operation: constant.ManifestCreate, wantCreated: true, wantStorage: "banyandb"},
+		{name: "Create an existing object", existing: newConfigMap("h2"), operation: constant.ManifestCreate, wantErr: true},
+		{name: "Replace a new object", operation: constant.ManifestReplace, wantCreated: true, wantStorage: "banyandb"},
+		{name: "Replace an existing object", existing: newConfigMap("h2"), operation: constant.ManifestReplace, wantStorage: "banyandb"},
+		{name: "Unsupported operation", operation: "patch", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.existing != nil {
+				objects = append(objects, tt.existing)
+			}
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{configMaps: "ConfigMapList"}, objects...)
+			dri := client.Resource(configMaps).Namespace("default")
+
+			created, err := operateObject(dri, newConfigMap("banyandb"), tt.operation)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("operateObject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if created != tt.wantCreated {
+				t.Errorf("operateObject() created = %v, want %v", created, tt.wantCreated)
+			}
+			got, err := dri.Get(context.Background(), "oap", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if storage, _, _ := unstructured.NestedString(got.Object, "data", "storage"); storage != tt.wantStorage {
+				t.Errorf("operateObject() storage = %s, want %s", storage, tt.wantStorage)
+			}
+		})
+	}
+}
+
+func TestDeleteAndWait(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	withFinalizer := newConfigMap("h2")
+	withFinalizer.SetFinalizers([]string{"e2e.skywalking.apache.org/protect"})
+	tests := []struct {
+		name     string
+		existing *unstructured.Unstructured
+		wantErr  string
+	}{
+		{name: "Delete an existing object", existing: newConfigMap("h2")},
+		{name: "Delete a missing object"},
+		{name: "Finalizers are not done", existing: withFinalizer, wantErr: "finalizers: [e2e.skywalking.apache.org/protect]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.existing != nil {
+				objects = append(objects, tt.existing)
+			}
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{configMaps: "ConfigMapList"}, objects...)
+			dri := client.Resource(configMaps).Namespace("default")
+			if tt.existing != nil && len(tt.existing.GetFinalizers()) > 0 {
+				// the fake client removes the object at once, keep it like the finalizers are not done
+				client.PrependReactor("delete", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, nil
+				})
+			}
+
+			err := deleteAndWait(dri, "oap", 50*time.Millisecond, 10*time.Millisecond)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("deleteAndWait() error = %v", err)
+				}
+				if _, err := dri.Get(context.Background(), "oap", metav1.GetOptions{}); err == nil {
+					t.Errorf("deleteAndWait() the object still exists")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("deleteAndWait() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestObjectDiff(t *testing.T) {
+	before, after := newConfigMap("h2"), newConfigMap("h2")
+	before.SetResourceVersion("1")
+	after.SetResourceVersion("2")
+	if diff := ObjectDiff(before, after); diff != "" {
+		t.Errorf("ObjectDiff() of the same objects = %s, want empty", diff)
+	}
+
+	after = newConfigMap("banyandb")
+	diff := ObjectDiff(before, after)
+	if !strings.Contains(diff, `-`) || !strings.Contains(diff, `"h2"`) || !strings.Contains(diff, `"banyandb"`) {
+		t.Errorf("ObjectDiff() = %s, want the change of storage", diff)
+	}
+}
+
+func TestResourceInterface(t *testing.T) {
+	discovery := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+			{Name: "namespaces", Kind: "Namespace"},
+		},
+	}}}}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	tests := []struct {
+		name          string
+		kind          string
+		objNamespace  string
+		namespace     string
+		wantNamespace string
+	}{
+		{name: "Default to the default namespace", kind: "ConfigMap", wantNamespace: "default"},
+		{name: "Default to the isolated namespace", kind: "ConfigMap", namespace: "e2e-1a2b3c4d", wantNamespace: "e2e-1a2b3c4d"},
+		{name: "Move the default namespace to the isolated one", kind: "ConfigMap", objNamespace: "default",
+			namespace: "e2e-1a2b3c4d", wantNamespace: "e2e-1a2b3c4d"},
+		{name: "Keep the other namespace", kind: "ConfigMap", objNamespace: "skywalking",
+			namespace: "e2e-1a2b3c4d", wantNamespace: "skywalking"},
+		{name: "Keep the cluster scoped object", kind: "Namespace", namespace: "e2e-1a2b3c4d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{configMaps: "ConfigMapList"})
+			obj := &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "v1",
+				"kind":       tt.kind,
+				"metadata":   map[string]any{"name": "oap"},
+			}}
+			if tt.objNamespace != "" {
+				obj.SetNamespace(tt.objNamespace)
+			}
+
+			dri, err := resourceInterface(discovery, client, obj, tt.namespace)
+			if err != nil {
+				t.Fatalf("resourceInterface() error = %v", err)
+			}
+			if obj.GetNamespace() != tt.wantNamespace {
+				t.Errorf("resourceInterface() namespace = %q, want %q", obj.GetNamespace(), tt.wantNamespace)
+			}
+			if tt.kind != "ConfigMap" {
+				return
+			}
+			if _, err := dri.Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Resource(configMaps).Namespace(tt.wantNamespace).Get(context.Background(), "oap", metav1.GetOptions{}); err != nil {
+				t.Errorf("the object is not created in namespace %s: %v", tt.wantNamespace, err)
+			}
+		})
+	}
+}
+
+func TestResolveNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		isolated  string
+		namespace string
+		want      string
+	}{
+		{name: "Kubeconfig context namespace", want: ""},
+		{name: "Default namespace without isolation", namespace: "default", want: "default"},
+		{name: "Empty namespace in isolation", isolated: "e2e-1a2b3c4d", want: "e2e-1a2b3c4d"},
+		{name: "Default namespace in isolation", isolated: "e2e-1a2b3c4d", namespace: "default", want: "e2e-1a2b3c4d"},
+		{name: "Other namespace in isolation", isolated: "e2e-1a2b3c4d", namespace: "skywalking", want: "skywalking"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := (&K8sClusterInfo{}).CopyClusterToNamespace(tt.isolated)
+			if got := cluster.ResolveNamespace(tt.namespace); got != tt.want {
+				t.Errorf("ResolveNamespace(%q) = %q, want %q", tt.namespace, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderKustomization(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base/kustomization.yaml": `
+resources:
+  - configmap.yaml
+`,
+		"base/configmap.yaml": `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: oap
+data:
+  storage: h2
+`,
+		"overlay/kustomization.yaml": `
+namespace: skywalking
+namePrefix: e2e-
+resources:
+  - ../base
+patches:
+  - patch: |-
+      apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: oap
+      data:
+        storage: banyandb
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := RenderKustomization(filepath.Join(dir, "overlay"))
+	if err != nil {
+		t.Fatalf("RenderKustomization() error = %v", err)
+	}
+	for _, want := range []string{"name: e2e-oap", "namespace: skywalking", "storage: banyandb"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("RenderKustomization() = %s, want to contain %q", content, want)
+		}
+	}
+
+	if _, err := RenderKustomization(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("RenderKustomization() of a missing directory should fail")
+	}
+}
@@ -0,0 +1,48 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestProcessStartTime(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	if err := StartProcessGroup(cmd); err != nil {
+		t.Skipf("failed to start the process: %v", err)
+	}
+	pid := cmd.Process.Pid
+
+	started, err := ProcessStartTime(pid)
+	if err != nil || started == "" {
+		t.Fatalf("ProcessStartTime() = %q, %v, want the start time", started, err)
+	}
+	if again, _ := ProcessStartTime(pid); again != started {
+		t.Errorf("ProcessStartTime() = %q, want the same start time %q", again, started)
+	}
+
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+	if _, err := ProcessStartTime(pid); !errors.Is(err, os.ErrProcessDone) {
+		t.Errorf("ProcessStartTime() error = %v after the process exited, want %v", err, os.ErrProcessDone)
+	}
+}
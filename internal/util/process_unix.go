@@ -0,0 +1,82 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+//go:build !windows
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// StartProcessGroup starts the command in a new process group, so it could be killed with its children.
+func StartProcessGroup(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd.Start()
+}
+
+// KillProcessGroup terminates the process group started by StartProcessGroup, and kills it if it's still alive
+// after the grace period, it's not an error if the process group has exited.
+func KillProcessGroup(pid int, grace time.Duration) error {
+	if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil {
+		if err == syscall.ESRCH {
+			return nil
+		}
+		return err
+	}
+	for deadline := time.Now().Add(grace); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if syscall.Kill(-pid, 0) == syscall.ESRCH {
+			return nil
+		}
+	}
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		return err
+	}
+	return nil
+}
+
+// ProcessStartTime returns the start time of the process, which identifies it together with the pid,
+// os.ErrProcessDone is returned if the process doesn't exist.
+func ProcessStartTime(pid int) (string, error) {
+	if stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		// the command in the parentheses may contain spaces, the start time is the 20th field after it
+		fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+		if len(fields) < 20 {
+			return "", fmt.Errorf("unexpected stat of process %d: %s", pid, stat)
+		}
+		return fields[19], nil
+	} else if _, statErr := os.Stat("/proc/self"); statErr == nil {
+		return "", os.ErrProcessDone
+	}
+
+	// no procfs, such as on macOS
+	output, err := exec.Command("ps", "-o", "lstart=", "-p", fmt.Sprint(pid)).Output()
+	started := strings.TrimSpace(string(output))
+	if started == "" {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", os.ErrProcessDone
+		}
+		return "", fmt.Errorf("get start time of process %d error: %v", pid, err)
+	}
+	return started, nil
+}
@@ -0,0 +1,69 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+//go:build windows
+
+package util
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// errorInvalidParameter is returned by OpenProcess if the process doesn't exist.
+const errorInvalidParameter syscall.Errno = 87
+
+// StartProcessGroup starts the command, the children of it are not tracked on Windows.
+func StartProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
+
+// KillProcessGroup kills the process started by StartProcessGroup, it's not an error if it has exited.
+func KillProcessGroup(pid int, _ time.Duration) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	if err := process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+// ProcessStartTime returns the creation time of the process, which identifies it together with the pid,
+// os.ErrProcessDone is returned if the process doesn't exist.
+func ProcessStartTime(pid int) (string, error) {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		if errors.Is(err, errorInvalidParameter) {
+			return "", os.ErrProcessDone
+		}
+		return "", err
+	}
+	defer syscall.CloseHandle(handle)
+
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(creation.Nanoseconds(), 10), nil
+}
@@ -20,20 +20,19 @@ package util
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 )
 
-var EnvRegularRegex = regexp.MustCompile(`\${(?P<ENV>[_A-Z0-9]+):(?P<DEF>.*)}`)
-
 // PathExist checks if a file/directory is exist.
 func PathExist(_path string) bool {
 	_, err := os.Stat(_path)
@@ -56,26 +55,47 @@ func ReadFileContent(filename string) (string, error) {
 }
 
 func GetIdentity() string {
-	runID := os.Getenv("GITHUB_RUN_ID")
-	if runID == "" {
-		return "skywalking_e2e"
+	identity := os.Getenv("GITHUB_RUN_ID")
+	if identity == "" {
+		identity = "skywalking_e2e"
+	}
+	// the matrix combinations could run in parallel, each of them has its own compose project
+	if MatrixIndex >= 0 {
+		identity = fmt.Sprintf("%s_matrix_%d", identity, MatrixIndex)
 	}
-	return runID
+	return identity
 }
 
 // ExecuteCommand executes the given command and returns the result.
+// The command runs with a snapshot of the environment store, so the concurrent commands don't affect each other,
+// and the variables changed or added by the command are exported into the store when it exits.
 func ExecuteCommand(cmd string) (stdout, stderr string, err error) {
-	hookScript, err := hookScript()
+	return ExecuteCommandContext(context.Background(), cmd)
+}
+
+// ExecuteCommandContext executes the given command like ExecuteCommand, and kills it if the context is done before it exits.
+func ExecuteCommandContext(ctx context.Context, cmd string) (stdout, stderr string, err error) {
+	envFile, err := os.CreateTemp("", "e2e-env-*")
+	if err != nil {
+		return "", "", err
+	}
+	envFile.Close()
+	defer os.Remove(envFile.Name())
+
+	hookScript, err := hookScript(envFile.Name())
 	if err != nil {
 		return "", "", err
 	}
 
-	// Propagate the env vars from sub-process back to parent process
-	defer ExportEnvVars(filepath.Join(WorkDir, ".env"))
+	environ := Env.Snapshot()
+	defer exportChangedEnvVars(envFile.Name(), environ)
 
 	cmd = hookScript + "\n" + cmd
 
-	command := exec.Command("bash", "-ec", cmd)
+	command := exec.CommandContext(ctx, "bash", "-ec", cmd)
+	// don't wait for the orphaned children holding the output after the command is killed
+	command.WaitDelay = time.Second
+	command.Env = environ
 	sout, serr := bytes.Buffer{}, bytes.Buffer{}
 	command.Stdout, command.Stderr = &sout, &serr
 
@@ -88,6 +108,17 @@ func ExecuteCommand(cmd string) (stdout, stderr string, err error) {
 	return sout.String(), serr.String(), nil
 }
 
+// RunCommand runs the program with the arguments rather than through the shell, so they're passed as they are.
+// It runs with a snapshot of the environment store like ExecuteCommand, but the variables changed by it are not exported.
+func RunCommand(ctx context.Context, name string, args ...string) (stdout, stderr string, err error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = Env.Snapshot()
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdoutBuf, &stderrBuf
+	err = cmd.Run()
+	return strings.TrimSpace(stdoutBuf.String()), strings.TrimSpace(stderrBuf.String()), err
+}
+
 //go:embed hook.sh
 var hookScriptTemplate string
 
@@ -95,7 +126,7 @@ type HookScriptTemplate struct {
 	EnvFile string
 }
 
-func hookScript() (string, error) {
+func hookScript(envFile string) (string, error) {
 	hookScript := bytes.Buffer{}
 
 	parse, err := template.New("hookScriptTemplate").Parse(hookScriptTemplate)
@@ -103,7 +134,6 @@ func hookScript() (string, error) {
 		return "", err
 	}
 
-	envFile := filepath.Join(WorkDir, ".env")
 	scriptData := HookScriptTemplate{EnvFile: envFile}
 	if err := parse.Execute(&hookScript, scriptData); err != nil {
 		return "", err
@@ -111,40 +141,43 @@ func hookScript() (string, error) {
 	return hookScript.String(), nil
 }
 
+// ExportEnvVars exports all the variables in the dotenv file into the environment store.
 func ExportEnvVars(envFile string) {
-	b, err := os.ReadFile(envFile)
+	vars, err := ReadDotenvFile(envFile)
 	if err != nil {
 		logger.Log.Warnf("failed to export environment variables, %v", err)
 		return
 	}
-	s := string(b)
+	Env.SetAll(vars)
+}
 
-	lines := strings.Split(s, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "#") {
-			continue
+// shellEnvVars are maintained by the shell itself, they are not exported into the environment store.
+var shellEnvVars = map[string]bool{"_": true, "SHLVL": true, "PWD": true, "OLDPWD": true}
+
+// exportChangedEnvVars exports the variables in the dotenv file written by the hook script into the environment store,
+// only the ones changed or added by the command, compared with the environment it ran with, are exported.
+func exportChangedEnvVars(envFile string, environ []string) {
+	vars, err := ReadDotenvFile(envFile)
+	if err != nil {
+		logger.Log.Warnf("failed to export environment variables, %v", err)
+		return
+	}
+	before := make(map[string]string, len(environ))
+	for _, env := range environ {
+		if key, val, ok := strings.Cut(env, "="); ok {
+			before[key] = val
 		}
-		kv := strings.SplitN(line, "=", 2)
-		if len(kv) != 2 {
+	}
+	changed := make(map[string]string)
+	for key, val := range vars {
+		if shellEnvVars[key] {
 			continue
 		}
-		key, val := kv[0], envOverwrite(kv[1])
-		// should only export env vars that are not already exist in parent process (Go process)
-		if err := os.Setenv(key, val); err != nil {
-			logger.Log.Warnf("failed to export environment variable %v=%v, %v", key, val, err)
+		if old, ok := before[key]; ok && old == val {
+			continue
 		}
+		logger.Log.Debugf("export environment variable %v changed by the command", key)
+		changed[key] = val
 	}
-}
-
-// envOverwrite replaces the environment variable with the value from the parent process
-func envOverwrite(val string) string {
-	groups := EnvRegularRegex.FindStringSubmatch(val)
-	if len(groups) == 0 {
-		return val
-	}
-
-	if v := os.Getenv(groups[1]); v != "" {
-		return v
-	}
-	return groups[2]
+	Env.SetAll(changed)
 }
@@ -32,18 +32,47 @@ const (
 func TestExportEnvVars(t *testing.T) {
 	// default value
 	ExportEnvVars(testEnvFile)
-	if os.Getenv(testEnvKey) != "abc" {
-		t.Errorf("expected %s, got %s", "abc", os.Getenv(testEnvKey))
+	if Env.Get(testEnvKey) != "abc" {
+		t.Errorf("expected %s, got %s", "abc", Env.Get(testEnvKey))
 	}
-	if os.Getenv(normalEnvKey) != "test" {
-		t.Errorf("expected %s, got %s", "test", os.Getenv(normalEnvKey))
+	if Env.Get(normalEnvKey) != "test" {
+		t.Errorf("expected %s, got %s", "test", Env.Get(normalEnvKey))
 	}
 
 	// override value from outside
 	var settingValFromOutside = "def"
 	os.Setenv("TEST", settingValFromOutside)
 	ExportEnvVars(testEnvFile)
-	if os.Getenv(testEnvKey) != settingValFromOutside {
-		t.Errorf("expected %s, got %s", settingValFromOutside, os.Getenv(testEnvKey))
+	if Env.Get(testEnvKey) != settingValFromOutside {
+		t.Errorf("expected %s, got %s", settingValFromOutside, Env.Get(testEnvKey))
+	}
+}
+
+func TestExecuteCommandExportsChangedEnvVars(t *testing.T) {
+	WorkDir = t.TempDir()
+	os.Setenv("E2E_TEST_UNCHANGED", "unchanged")
+	defer os.Unsetenv("E2E_TEST_UNCHANGED")
+
+	_, stderr, err := ExecuteCommand(`
+export E2E_TEST_MULTILINE=$'line 1\nline 2'
+export E2E_TEST_QUOTED='"quoted" $HOME \\ back'
+unset E2E_TEST_UNCHANGED
+`)
+	if err != nil {
+		t.Fatalf("ExecuteCommand() error = %v, stderr = %s", err, stderr)
+	}
+
+	if got := Env.Get("E2E_TEST_MULTILINE"); got != "line 1\nline 2" {
+		t.Errorf("E2E_TEST_MULTILINE = %q", got)
+	}
+	if got := Env.Get("E2E_TEST_QUOTED"); got != `"quoted" $HOME \\ back` {
+		t.Errorf("E2E_TEST_QUOTED = %q", got)
+	}
+	if _, ok := os.LookupEnv("E2E_TEST_MULTILINE"); ok {
+		t.Errorf("E2E_TEST_MULTILINE is exported to the e2e process")
+	}
+	// removed variables are not propagated
+	if got := Env.Get("E2E_TEST_UNCHANGED"); got != "unchanged" {
+		t.Errorf("E2E_TEST_UNCHANGED = %q", got)
 	}
 }